@@ -2,6 +2,7 @@ package backtester
 
 import (
 	"fmt"
+	"math"
 	"sync"
 	"time"
 )
@@ -9,11 +10,21 @@ import (
 // Trade represents a single trade
 type Trade struct {
 	ID         string    `json:"id"`
+	OrderID    string    `json:"order_id,omitempty"`
+	Symbol     string    `json:"symbol"`
 	Price      float64   `json:"price"`
 	Qty        float64   `json:"qty"`
 	Time       time.Time `json:"time"`
 	IsBuy      bool      `json:"is_buy"`
-	Commission float64   `json:"commission"`
+	Commission float64   `json:"commission"`          // Negative for a maker rebate
+	Liquidity  Liquidity `json:"liquidity,omitempty"` // Whether the fill made or took liquidity
+	Slippage   float64   `json:"slippage,omitempty"`  // Cost of slippage against the last trade price, in quote currency
+	Impact     float64   `json:"impact,omitempty"`    // Cost of market impact on top of slippage, in quote currency
+
+	TriggerTime *time.Time `json:"trigger_time,omitempty"` // When the stop or take-profit order behind the trade triggered
+
+	Reason string             `json:"reason,omitempty"` // Why the strategy traded, from the signal
+	Values map[string]float64 `json:"values,omitempty"` // Key values behind the decision
 }
 
 // Position represents a current position
@@ -31,13 +42,45 @@ type Portfolio struct {
 	Equity    float64             `json:"equity"`
 }
 
+// OrderType distinguishes immediately executed orders from resting ones
+type OrderType string
+
+const (
+	MarketOrder       OrderType = ""              // Fills immediately at the current price
+	LimitOrder        OrderType = "LIMIT"         // Rests until the market trades at Price or better
+	StopOrder         OrderType = "STOP"          // Market order once the price moves through StopPrice against the side
+	StopLimitOrder    OrderType = "STOP_LIMIT"    // Limit order at Price once the price moves through StopPrice against the side
+	TakeProfitOrder   OrderType = "TAKE_PROFIT"   // Market order once the price reaches StopPrice in favor of the side
+	TrailingStopOrder OrderType = "TRAILING_STOP" // Stop order whose StopPrice trails the best price by Trail or TrailPercent
+)
+
 // Order represents a trading order
 type Order struct {
+	ID     string    `json:"id,omitempty"` // Assigned by the exchange unless set by the strategy
 	Symbol string    `json:"symbol"`
+	Type   OrderType `json:"type,omitempty"`
 	Qty    float64   `json:"qty"`
 	Price  float64   `json:"price"`
 	IsBuy  bool      `json:"is_buy"`
 	Time   time.Time `json:"time"`
+	Filled float64   `json:"filled,omitempty"` // Quantity filled so far, set by the exchange
+
+	StopPrice    float64    `json:"stop_price,omitempty"`    // Trigger price of conditional orders
+	Trail        float64    `json:"trail,omitempty"`         // Absolute trailing distance
+	TrailPercent float64    `json:"trail_percent,omitempty"` // Trailing distance in percent of the best price, if Trail is zero
+	TriggerTime  *time.Time `json:"trigger_time,omitempty"`  // Set by the exchange when a conditional order triggers
+
+	TimeInForce TimeInForce `json:"time_in_force,omitempty"` // GTC when empty
+	ExpireTime  *time.Time  `json:"expire_time,omitempty"`   // When a GTD order is cancelled
+	PostOnly    bool        `json:"post_only,omitempty"`     // Rejected instead of taking liquidity on arrival
+
+	OCO     string   `json:"oco,omitempty"`     // Group ID; a fill cancels the other resting orders of the group
+	Bracket []*Order `json:"bracket,omitempty"` // Exit legs placed opposite the order once it fills, as one OCO group
+
+	Reason string             `json:"reason,omitempty"`
+	Values map[string]float64 `json:"values,omitempty"`
+
+	unplaced float64 // Filled quantity whose bracket legs are held back as too small
 }
 
 // CommissionCalculator handles commission calculations
@@ -117,6 +160,7 @@ func generateTradeID() string {
 type PortfolioManager struct {
 	portfolio            *Portfolio
 	commissionCalculator *CommissionCalculator
+	fees                 *FeeSchedule // Replaces the flat commission rate when set
 }
 
 // NewPortfolioManager creates a new portfolio manager
@@ -131,6 +175,11 @@ func NewPortfolioManager(initialCash float64, commissionRate float64) *Portfolio
 	}
 }
 
+// SetFeeSchedule charges maker and taker fees by volume tier instead of the flat commission rate
+func (pm *PortfolioManager) SetFeeSchedule(fees *FeeSchedule) {
+	pm.fees = fees
+}
+
 // GetPortfolio returns the current portfolio
 func (pm *PortfolioManager) GetPortfolio() *Portfolio {
 	return pm.portfolio
@@ -152,14 +201,27 @@ func (pm *PortfolioManager) UpdateEquity(currentPrices map[string]float64) {
 	pm.portfolio.Equity = equity
 }
 
-// ExecuteOrder executes an order and updates the portfolio
+// ExecuteOrder executes an order at its price and updates the portfolio
 func (pm *PortfolioManager) ExecuteOrder(order *Order) (*Trade, error) {
+	return pm.Fill(order, order.Price, order.Qty, order.Time)
+}
+
+// Fill executes qty of an order at price and time as a taker and updates the portfolio
+func (pm *PortfolioManager) Fill(order *Order, price, qty float64, at time.Time) (*Trade, error) {
+	return pm.FillAs(order, price, qty, at, Taker)
+}
+
+// FillAs executes qty of an order at price and time with the given liquidity role and updates the portfolio
+func (pm *PortfolioManager) FillAs(order *Order, price, qty float64, at time.Time, liquidity Liquidity) (*Trade, error) {
 	// Calculate commission
-	commission := pm.commissionCalculator.CalculateCommission(order.Price, order.Qty)
+	commission := pm.commissionCalculator.CalculateCommission(price, qty)
+	if pm.fees != nil {
+		commission = pm.fees.Commission(price, qty, liquidity, at)
+	}
 
 	// Check if we have enough cash for buy order
 	if order.IsBuy {
-		cost := order.Price*order.Qty + commission
+		cost := price*qty + commission
 		if pm.portfolio.Cash < cost {
 			return nil, &InsufficientFundsError{Available: pm.portfolio.Cash, Required: cost}
 		}
@@ -168,20 +230,29 @@ func (pm *PortfolioManager) ExecuteOrder(order *Order) (*Trade, error) {
 	// Execute trade
 	trade := &Trade{
 		ID:         generateTradeID(),
-		Price:      order.Price,
-		Qty:        order.Qty,
-		Time:       order.Time,
+		OrderID:    order.ID,
+		Symbol:     order.Symbol,
+		Price:      price,
+		Qty:        qty,
+		Time:       at,
 		IsBuy:      order.IsBuy,
 		Commission: commission,
+		Liquidity:  liquidity,
+
+		TriggerTime: order.TriggerTime,
+		Reason:      order.Reason,
+		Values:      order.Values,
 	}
 
 	// Update portfolio
 	if order.IsBuy {
-		pm.portfolio.Cash -= order.Price*order.Qty + commission
-		pm.updatePosition(order.Symbol, order.Qty, order.Price, order.Time, true)
+		pm.portfolio.Cash -= price*qty + commission
 	} else {
-		pm.portfolio.Cash += order.Price*order.Qty - commission
-		pm.updatePosition(order.Symbol, order.Qty, order.Price, order.Time, false)
+		pm.portfolio.Cash += price*qty - commission
+	}
+	pm.updatePosition(order.Symbol, qty, price, at, order.IsBuy)
+	if pm.fees != nil {
+		pm.fees.Record(price, qty, at)
 	}
 
 	return trade, nil
@@ -213,7 +284,7 @@ func (pm *PortfolioManager) updatePosition(symbol string, qty, price float64, ti
 		// Update existing position
 		if isBuy {
 			// Adding to long position or closing short position
-			newQty := position.Qty + qty
+			newQty := snapFlat(position.Qty+qty, qty)
 			if newQty > 0 {
 				// Still long
 				newAvgPrice := (position.AvgEntryPrice*position.Qty + price*qty) / newQty
@@ -230,7 +301,7 @@ func (pm *PortfolioManager) updatePosition(symbol string, qty, price float64, ti
 			}
 		} else {
 			// Adding to short position or closing long position
-			newQty := position.Qty - qty
+			newQty := snapFlat(position.Qty-qty, qty)
 			if newQty < 0 {
 				// Still short
 				newAvgPrice := (position.AvgEntryPrice*-position.Qty + price*qty) / -newQty
@@ -255,6 +326,14 @@ func (pm *PortfolioManager) updatePosition(symbol string, qty, price float64, ti
 	}
 }
 
+// snapFlat returns zero for a position left within rounding of flat by a fill of qty
+func snapFlat(positionQty, qty float64) float64 {
+	if math.Abs(positionQty) <= qty*quantityEpsilon {
+		return 0
+	}
+	return positionQty
+}
+
 // InsufficientFundsError represents an error when there are insufficient funds
 type InsufficientFundsError struct {
 	Available float64
@@ -0,0 +1,142 @@
+package backtester
+
+import (
+	"sort"
+	"time"
+)
+
+// Bar represents OHLCV data aggregated from trades over a time interval
+type Bar struct {
+	Symbol    string        `json:"symbol"`
+	Timeframe time.Duration `json:"timeframe"`
+	Start     int64         `json:"start"` // Milliseconds since epoch, inclusive
+	End       int64         `json:"end"`   // Milliseconds since epoch, exclusive
+	Open      float64       `json:"open"`
+	High      float64       `json:"high"`
+	Low       float64       `json:"low"`
+	Close     float64       `json:"close"`
+	Volume    float64       `json:"volume"`
+	Trades    int           `json:"trades"`
+}
+
+// BarAggregator builds time bars of one interval per symbol from trades.
+// Intervals without trades produce no bar.
+type BarAggregator struct {
+	timeframe time.Duration
+	open      map[string]*Bar
+}
+
+// NewBarAggregator creates an aggregator for bars of the given interval
+func NewBarAggregator(timeframe time.Duration) *BarAggregator {
+	return &BarAggregator{
+		timeframe: timeframe,
+		open:      make(map[string]*Bar),
+	}
+}
+
+// Close returns the bars of all symbols that end at or before time t (ms), oldest first
+func (a *BarAggregator) Close(t int64) []Bar {
+	var closed []Bar
+	for symbol, bar := range a.open {
+		if bar.End <= t {
+			closed = append(closed, *bar)
+			delete(a.open, symbol)
+		}
+	}
+	sortBars(closed)
+	return closed
+}
+
+// Update adds a trade to the open bar of its symbol. Call Close first so the
+// trade does not land in a bar that has already ended.
+func (a *BarAggregator) Update(point ChartPoint) {
+	bar, exists := a.open[point.Symbol]
+	if !exists {
+		interval := a.timeframe.Milliseconds()
+		start := point.Time - point.Time%interval
+		bar = &Bar{
+			Symbol:    point.Symbol,
+			Timeframe: a.timeframe,
+			Start:     start,
+			End:       start + interval,
+			Open:      point.Price,
+			High:      point.Price,
+			Low:       point.Price,
+		}
+		a.open[point.Symbol] = bar
+	}
+
+	if point.Price > bar.High {
+		bar.High = point.Price
+	}
+	if point.Price < bar.Low {
+		bar.Low = point.Price
+	}
+	bar.Close = point.Price
+	bar.Volume += point.Qty
+	bar.Trades++
+}
+
+// sortBars orders bars by end time, then shorter timeframes first, then symbol
+func sortBars(bars []Bar) {
+	sort.Slice(bars, func(i, j int) bool {
+		if bars[i].End != bars[j].End {
+			return bars[i].End < bars[j].End
+		}
+		if bars[i].Timeframe != bars[j].Timeframe {
+			return bars[i].Timeframe < bars[j].Timeframe
+		}
+		return bars[i].Symbol < bars[j].Symbol
+	})
+}
+
+// point returns a market point at the bar close for translating bar signals into orders
+func (b Bar) point() ChartPoint {
+	return ChartPoint{Symbol: b.Symbol, Time: b.End, Price: b.Close}
+}
+
+// barSchedule aggregates the timeframes a strategy subscribed to
+type barSchedule struct {
+	listener    BarListener
+	aggregators []*BarAggregator
+}
+
+// newBarSchedule creates aggregators for the timeframes of a BarListener strategy
+func newBarSchedule(strategy Strategy) *barSchedule {
+	schedule := &barSchedule{}
+	listener, ok := strategy.(BarListener)
+	if !ok {
+		return schedule
+	}
+	schedule.listener = listener
+	for _, timeframe := range listener.Timeframes() {
+		if timeframe >= time.Millisecond {
+			schedule.aggregators = append(schedule.aggregators, NewBarAggregator(timeframe))
+		}
+	}
+	return schedule
+}
+
+// close returns the bars of all timeframes that end at or before time t (ms), in time order
+func (s *barSchedule) close(t int64) []Bar {
+	var closed []Bar
+	for _, aggregator := range s.aggregators {
+		closed = append(closed, aggregator.Close(t)...)
+	}
+	sortBars(closed)
+	return closed
+}
+
+// update closes the bars that ended before the point, then adds the point to the open bars.
+// Closed bars are returned in time order.
+func (s *barSchedule) update(point ChartPoint) []Bar {
+	var closed []Bar
+	for _, aggregator := range s.aggregators {
+		closed = append(closed, aggregator.Close(point.Time)...)
+		aggregator.Update(point)
+	}
+	if len(s.aggregators) > 1 {
+		sortBars(closed)
+	}
+	return closed
+}
@@ -0,0 +1,91 @@
+package backtester
+
+import "errors"
+
+// ErrInvalidConditional rejects conditional orders without a usable trigger
+var ErrInvalidConditional = errors.New("conditional order needs a positive stop price, trail or limit price")
+
+// conditional reports whether the order waits for a trigger before it reaches the book
+func (o *Order) conditional() bool {
+	switch o.Type {
+	case StopOrder, StopLimitOrder, TakeProfitOrder, TrailingStopOrder:
+		return o.TriggerTime == nil
+	}
+	return false
+}
+
+// validateConditional checks that a conditional order can trigger
+func validateConditional(order *Order) error {
+	switch order.Type {
+	case TrailingStopOrder:
+		if order.Trail <= 0 && order.TrailPercent <= 0 {
+			return ErrInvalidConditional
+		}
+	case StopLimitOrder:
+		if order.StopPrice <= 0 || order.Price <= 0 {
+			return ErrInvalidConditional
+		}
+	default:
+		if order.StopPrice <= 0 {
+			return ErrInvalidConditional
+		}
+	}
+	return nil
+}
+
+// triggers reports whether a trade at price sets off the order
+func triggers(order *Order, price float64) bool {
+	if order.Type == TakeProfitOrder {
+		// Take profit buys on the way down and sells on the way up
+		if order.IsBuy {
+			return price <= order.StopPrice
+		}
+		return price >= order.StopPrice
+	}
+	if order.IsBuy {
+		return price >= order.StopPrice
+	}
+	return price <= order.StopPrice
+}
+
+// trail moves the stop of a trailing stop order behind the best price seen
+func (r *restingOrder) trail(price float64) {
+	order := r.order
+	if r.extreme == 0 || (order.IsBuy && price < r.extreme) || (!order.IsBuy && price > r.extreme) {
+		r.extreme = price
+	}
+
+	offset := order.Trail
+	if offset <= 0 {
+		offset = r.extreme * order.TrailPercent / 100
+	}
+	if order.IsBuy {
+		order.StopPrice = r.extreme + offset
+	} else {
+		order.StopPrice = r.extreme - offset
+	}
+}
+
+// trigger checks a waiting conditional order against a trade, reporting whether the order
+// is done. Triggered stop-limit orders that are not marketable join the book unless their
+// time in force forbids it; other triggered orders take liquidity at the trade price.
+func (ex *Exchange) trigger(r *restingOrder, point ChartPoint) ([]Execution, bool) {
+	order := r.order
+	if order.Type == TrailingStopOrder {
+		r.trail(point.Price)
+	}
+	if !triggers(order, point.Price) {
+		return nil, false
+	}
+
+	triggerTime := point.Timestamp()
+	order.TriggerTime = &triggerTime
+	if order.Type == StopLimitOrder && !crosses(order, point.Price) {
+		if err := notFilledError(order); err != nil {
+			return []Execution{{Order: order, Err: err}}, true
+		}
+		r.ahead = ex.queue.Ahead
+		return nil, false
+	}
+	return ex.execute(order, point.Price)
+}
@@ -0,0 +1,110 @@
+package backtester
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConditionalOrders(t *testing.T) {
+	tests := []struct {
+		name      string
+		order     Order
+		prices    []float64 // Trades after submission at 100
+		wantFill  int       // Index of the filling trade, or -1 for none
+		wantPrice float64
+	}{
+		{"stop sells once the price falls to it", Order{Type: StopOrder, StopPrice: 98}, []float64{99, 97.5}, 1, 97.5},
+		{"stop buys once the price rises to it", Order{Type: StopOrder, StopPrice: 102, IsBuy: true}, []float64{101, 102}, 1, 102},
+		{"take profit sells once the price rises to it", Order{Type: TakeProfitOrder, StopPrice: 103}, []float64{98, 103.5}, 1, 103.5},
+		{"trailing stop follows the high", Order{Type: TrailingStopOrder, Trail: 2}, []float64{103, 102, 100.9}, 2, 100.9},
+		{"trailing stop does not trigger within the trail", Order{Type: TrailingStopOrder, Trail: 2}, []float64{103, 101.5}, -1, 0},
+		{"percent trailing stop", Order{Type: TrailingStopOrder, TrailPercent: 2}, []float64{105, 103, 102.8}, 2, 102.8},
+		{"trailing buy stop follows the low", Order{Type: TrailingStopOrder, Trail: 1, IsBuy: true}, []float64{97, 97.5, 98}, 2, 98},
+		{"stop-limit fills at its limit after resting", Order{Type: StopLimitOrder, StopPrice: 99, Price: 98.5}, []float64{98, 98.2, 98.7}, 2, 98.5},
+		{"stop-limit fills on a trigger inside the limit", Order{Type: StopLimitOrder, StopPrice: 99, Price: 98.5}, []float64{98.9}, 0, 98.9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := newTestExchange(QueueModel{})
+			ex.Match(trade(0, 100, 1))
+			order := tt.order
+			order.Symbol, order.Qty = testSymbol, 1
+			if executions := ex.Submit(&order); len(executions) != 0 {
+				t.Fatalf("order executed on submission: %v", firstErr(executions))
+			}
+
+			fill := -1
+			var price float64
+			for i, p := range tt.prices {
+				if filled := trades(ex.Match(trade(int64(i+1), p, 1))); len(filled) > 0 {
+					fill, price = i, filled[0].Price
+					if filled[0].TriggerTime == nil {
+						t.Fatalf("trade has no trigger time")
+					}
+					break
+				}
+			}
+			if fill != tt.wantFill || price != tt.wantPrice {
+				t.Fatalf("filled at %v on trade %d, want %v on trade %d", price, fill, tt.wantPrice, tt.wantFill)
+			}
+		})
+	}
+}
+
+func TestStopLimitRests(t *testing.T) {
+	ex := newTestExchange(QueueModel{})
+	ex.Match(trade(0, 100, 1))
+	order := &Order{Symbol: testSymbol, Type: StopLimitOrder, StopPrice: 99, Price: 98.5, Qty: 1}
+	ex.Submit(order)
+
+	// Triggered below the limit, the order joins the book as a limit order
+	if executions := ex.Match(trade(1, 98, 1)); len(executions) != 0 {
+		t.Fatalf("stop-limit executed below its limit: %v", firstErr(executions))
+	}
+	if order.TriggerTime == nil || order.conditional() {
+		t.Fatalf("stop-limit was not triggered")
+	}
+	if open := ex.OpenOrders(); len(open) != 1 || open[0] != order {
+		t.Fatalf("triggered stop-limit is not resting")
+	}
+
+	// Back above the stop it stays on the book instead of waiting for a new trigger
+	filled := trades(ex.Match(trade(2, 99.5, 1)))
+	if len(filled) != 1 || filled[0].Price != 98.5 || filled[0].Liquidity != Maker {
+		t.Fatalf("want a maker fill at 98.5, got %+v", filled)
+	}
+}
+
+func TestConditionalRejections(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   Order
+		price   float64 // Trade after submission at 100
+		wantErr error
+	}{
+		{"stop without a stop price", Order{Type: StopOrder}, 0, ErrInvalidConditional},
+		{"stop-limit without a limit", Order{Type: StopLimitOrder, StopPrice: 99}, 0, ErrInvalidConditional},
+		{"trailing stop without a trail", Order{Type: TrailingStopOrder}, 0, ErrInvalidConditional},
+		{"IOC stop-limit triggered outside its limit", Order{Type: StopLimitOrder, StopPrice: 99, Price: 98.5, TimeInForce: IOC}, 98, ErrIOCNotFilled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := newTestExchange(QueueModel{})
+			ex.Match(trade(0, 100, 1))
+			order := tt.order
+			order.Symbol, order.Qty = testSymbol, 1
+			err := firstErr(ex.Submit(&order))
+			if tt.price > 0 {
+				err = firstErr(ex.Match(trade(1, tt.price, 1)))
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if len(ex.OpenOrders()) != 0 {
+				t.Fatalf("rejected order is resting")
+			}
+		})
+	}
+}
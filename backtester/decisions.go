@@ -0,0 +1,70 @@
+package backtester
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Decision records a non-HOLD signal and what became of it
+type Decision struct {
+	Time    int64              `json:"time"`
+	Symbol  string             `json:"symbol"`
+	Action  string             `json:"action"`
+	Target  float64            `json:"target,omitempty"`
+	Reason  string             `json:"reason,omitempty"`
+	Values  map[string]float64 `json:"values,omitempty"`
+	Warmup  bool               `json:"warmup,omitempty"` // Produced from warm-up data, so never submitted
+	Orders  int                `json:"orders"`           // New and replaced orders; zero when there was nothing to do
+	Fills   int                `json:"fills"`            // Fills on submission; an order may fill in several
+	Resting int                `json:"resting"`          // Orders left resting or working after submission
+	Rejects []string           `json:"rejects,omitempty"`
+}
+
+// SetDecisionLog writes every non-HOLD signal to w as newline-delimited JSON.
+// Logging stops at the first write error.
+func (be *BacktestEngine) SetDecisionLog(w io.Writer) {
+	be.decisionLog = json.NewEncoder(w)
+}
+
+// newDecision starts a log entry for a signal, or returns nil if it is not logged
+func (be *BacktestEngine) newDecision(signal Signal, point ChartPoint, orders []*Order) *Decision {
+	if be.decisionLog == nil || signal.idle() {
+		return nil
+	}
+	return &Decision{
+		Time:   point.Time,
+		Symbol: point.Symbol,
+		Action: signal.Action,
+		Target: signal.Target,
+		Reason: signal.Reason,
+		Values: signal.Values,
+		Orders: len(orders) + len(signal.Replace),
+	}
+}
+
+// record counts the outcome of an order submission
+func (d *Decision) record(executions []Execution, resting bool) {
+	if d == nil {
+		return
+	}
+	if resting {
+		d.Resting++
+	}
+	for _, execution := range executions {
+		if execution.Err != nil {
+			d.Rejects = append(d.Rejects, execution.Err.Error())
+		} else {
+			d.Fills++
+		}
+	}
+}
+
+// logDecision writes a decision to the log
+func (be *BacktestEngine) logDecision(decision *Decision) {
+	if decision == nil || be.decisionLog == nil {
+		return
+	}
+	if err := be.decisionLog.Encode(decision); err != nil {
+		be.decisionLog = nil
+	}
+}
@@ -1,10 +1,15 @@
 package backtester
 
 import (
-	"hft-backtester/strategies"
+	"encoding/json"
+	"errors"
+	"math"
 	"time"
 )
 
+// ErrWarmup rejects orders produced while the strategy is fed warm-up data
+var ErrWarmup = errors.New("orders are not allowed during warm-up")
+
 // BacktestResult represents the results of a backtest
 type BacktestResult struct {
 	Trades      []*Trade      `json:"trades"`
@@ -13,6 +18,30 @@ type BacktestResult struct {
 	FinalEquity float64       `json:"final_equity"`
 	EquityCurve []EquityPoint `json:"equity_curve"`
 	PriceData   []ChartPoint  `json:"price_data,omitempty"` // Added for visualization
+
+	Series map[string][]SeriesPoint `json:"series,omitempty"` // Indicator values published by the strategy
+
+	SymbolPnL map[string]float64 `json:"symbol_pnl"` // Realized plus unrealized PnL per symbol, after commission
+	TotalPnL  float64            `json:"total_pnl"`
+
+	Attribution map[string]*Attribution `json:"attribution,omitempty"` // Signals per child of a composite strategy
+
+	Rejections map[string]int `json:"rejections,omitempty"` // Rejected, expired and unfilled IOC/FOK orders by reason
+
+	Costs Costs `json:"costs"`
+}
+
+// Costs totals the trading costs of the fills, in quote currency
+type Costs struct {
+	Commission float64 `json:"commission"`
+	Slippage   float64 `json:"slippage"`
+	Impact     float64 `json:"impact"`
+}
+
+// Attribution summarizes the signals of one child of a composite strategy
+type Attribution struct {
+	Signals map[string]int `json:"signals"` // Non-HOLD signals by action
+	Aligned int            `json:"aligned"` // Combined target changes the child agreed with
 }
 
 // EquityPoint represents a point in the equity curve
@@ -21,156 +50,396 @@ type EquityPoint struct {
 	Equity float64 `json:"equity"`
 }
 
+// SeriesPoint represents a point in a named indicator series
+type SeriesPoint struct {
+	Time  int64   `json:"time"`
+	Value float64 `json:"value"`
+}
+
 // ChartPoint represents a data point for charting
 type ChartPoint struct {
-	Time  int64   `json:"time"`
-	Price float64 `json:"price"`
+	Symbol       string  `json:"symbol,omitempty"` // Empty means Config.Symbol
+	Time         int64   `json:"time"`
+	Price        float64 `json:"price"`
+	Qty          float64 `json:"qty,omitempty"`
+	IsBuyerMaker bool    `json:"is_buyer_maker,omitempty"` // True when the seller was the aggressor
+}
+
+// Timestamp returns the point time (milliseconds since epoch) as time.Time
+func (p ChartPoint) Timestamp() time.Time {
+	return time.UnixMilli(p.Time)
+}
+
+// Config holds the backtest settings
+type Config struct {
+	Symbol         string   `json:"symbol"`            // Traded symbol (defaults to BTCUSDT)
+	Symbols        []string `json:"symbols,omitempty"` // All symbols in the data when replaying several
+	InitialCash    float64  `json:"initial_cash"`      // Starting cash in USD
+	CommissionRate float64  `json:"commission_rate"`   // Commission rate (0.0005 = 0.05%)
+	PositionSize   float64  `json:"position_size"`     // Position size in USD
+
+	Queue         QueueModel    `json:"queue"`                   // When resting limit orders fill
+	Latency       LatencyConfig `json:"latency"`                 // Delays between the strategy and the exchange
+	Participation float64       `json:"participation,omitempty"` // Fraction of each trade's quantity our orders may fill, zero for no cap
 }
 
 // BacktestEngine represents the backtesting engine
 type BacktestEngine struct {
+	config           Config
 	portfolioManager *PortfolioManager
 	tradeExecutor    *TradeExecutor
-	positionSize     float64 // Position size in USD
+	exchange         *Exchange
+	decisionLog      *json.Encoder   // Nil unless SetDecisionLog was called
+	latency          *latencySampler // Nil without latency
+	outbox           []pendingSignal // Signals on their way to the exchange, by arrival time
+	inbox            []pendingReport // Executions on their way to the strategy, by delivery time
 }
 
 // NewBacktestEngine creates a new backtesting engine
-func NewBacktestEngine(initialCash float64, commissionRate float64, positionSize float64) *BacktestEngine {
+func NewBacktestEngine(config Config) *BacktestEngine {
+	if config.Symbol == "" {
+		config.Symbol = "BTCUSDT"
+	}
+	portfolioManager := NewPortfolioManager(config.InitialCash, config.CommissionRate)
+	exchange := NewExchange(portfolioManager, config.Queue)
+	exchange.SetParticipation(config.Participation)
 	return &BacktestEngine{
-		portfolioManager: NewPortfolioManager(initialCash, commissionRate),
-		tradeExecutor:    NewTradeExecutor(commissionRate),
-		positionSize:     positionSize,
+		config:           config,
+		portfolioManager: portfolioManager,
+		tradeExecutor:    NewTradeExecutor(config.CommissionRate),
+		exchange:         exchange,
+		latency:          newLatencySampler(config.Latency),
 	}
 }
 
+// SetSlippageModel sets the model pricing orders that take liquidity; nil fills at the last trade price
+func (be *BacktestEngine) SetSlippageModel(model SlippageModel) {
+	be.exchange.SetSlippageModel(model)
+}
+
+// SetFeeSchedule charges maker and taker fees by volume tier instead of Config.CommissionRate; nil restores the flat rate
+func (be *BacktestEngine) SetFeeSchedule(fees *FeeSchedule) {
+	be.portfolioManager.SetFeeSchedule(fees)
+}
+
+// SetSymbolFilters checks orders against per-symbol tick size, lot size and notional rules,
+// rounding orders off the tick or step unless reject is set
+func (be *BacktestEngine) SetSymbolFilters(filters map[string]SymbolFilters, reject bool) {
+	be.exchange.SetSymbolFilters(filters, reject)
+}
+
+// SetImpactModel sets the market impact charged to orders that take liquidity; nil for none
+func (be *BacktestEngine) SetImpactModel(model *ImpactModel) {
+	be.exchange.SetImpactModel(model)
+}
+
 // Run executes a backtest with a given strategy
-func (be *BacktestEngine) Run(data []ChartPoint, strategy interface{}) *BacktestResult {
+func (be *BacktestEngine) Run(data []ChartPoint, strategy Strategy) *BacktestResult {
+	return be.RunWithWarmup(nil, data, strategy)
+}
+
+// RunWithWarmup executes a backtest after priming the strategy with warmup data preceding the window.
+// Warm-up points are passed to OnStart and then fed through OnBar and OnTick; any orders they
+// produce are reported to the strategy as rejected with ErrWarmup.
+// With Config.Latency set, signals reach the exchange and executions reach the strategy
+// after the sampled delays; signals still in flight at the end are rejected with ErrNotArrived.
+func (be *BacktestEngine) RunWithWarmup(warmup, data []ChartPoint, strategy Strategy) *BacktestResult {
 	result := &BacktestResult{
 		Trades:      make([]*Trade, 0),
 		StartTime:   time.Now(),
 		EquityCurve: make([]EquityPoint, 0),
 	}
 
-	// Initialize Bollinger Bands strategy if provided
-	var bbStrategy *strategies.BollingerBandsStrategy
+	if starter, ok := strategy.(Starter); ok {
+		starter.OnStart(be.config, warmup)
+	}
+
+	publisher, hasSeries := strategy.(SeriesPublisher)
+	if hasSeries {
+		result.Series = make(map[string][]SeriesPoint)
+	}
+
+	timers := newTimerSchedule(strategy, data)
+	bars := newBarSchedule(strategy)
+
 	if strategy != nil {
-		if bb, ok := strategy.(*strategies.BollingerBandsStrategy); ok {
-			bbStrategy = bb
+		for _, point := range warmup {
+			if point.Symbol == "" {
+				point.Symbol = be.config.Symbol
+			}
+			for _, bar := range bars.update(point) {
+				be.rejectSignal(bars.listener.OnBar(bar), bar.point(), strategy)
+			}
+			be.rejectSignal(strategy.OnTick(point), point, strategy)
+		}
+
+		// Bars that ended before the window are warm-up bars too, so none closes
+		// on the first tick before the exchange has seen a trade
+		if len(warmup) > 0 && len(data) > 0 {
+			for _, bar := range bars.close(data[0].Time) {
+				be.rejectSignal(bars.listener.OnBar(bar), bar.point(), strategy)
+			}
 		}
 	}
 
 	// Process each data point
 	for _, point := range data {
+		if point.Symbol == "" {
+			point.Symbol = be.config.Symbol
+		}
+
+		// Deliver bars that closed and timers that elapsed before this tick, in time order
+		for _, bar := range bars.update(point) {
+			be.deliver(time.UnixMilli(bar.End), strategy, result)
+			timers.fire(time.UnixMilli(bar.End))
+			be.send(bars.listener.OnBar(bar), bar.point(), strategy, result)
+		}
+		be.deliver(point.Timestamp(), strategy, result)
+		timers.fire(point.Timestamp())
+
+		// Fill resting orders crossed by this trade
+		be.report(be.exchange.Match(point), strategy, result)
+
 		// Update equity curve
-		be.portfolioManager.UpdateEquity(map[string]float64{"BTCUSDT": point.Price})
+		be.portfolioManager.UpdateEquity(be.exchange.LastPrices())
 		result.EquityCurve = append(result.EquityCurve, EquityPoint{
 			Time:   point.Time,
 			Equity: be.portfolioManager.GetPortfolio().Equity,
 		})
 
-		var order *Order
-		if bbStrategy != nil {
-			// Use strategy to determine action
-			signal := bbStrategy.GetSignal(point.Price)
-
-			// Check current position
-			currentPosition := 0.0
-			if pos, exists := be.portfolioManager.GetPortfolio().Positions["BTCUSDT"]; exists {
-				currentPosition = pos.Qty
-			}
-
-			// Create order based on signal
-			switch signal.Action {
-			case "BUY":
-				if currentPosition < 0 {
-					// Close short position
-					order = &Order{
-						Symbol: "BTCUSDT",
-						Qty:    -currentPosition, // Close entire position
-						Price:  point.Price,
-						IsBuy:  true,
-						Time:   time.Unix(point.Time/1000, 0),
-					}
-				} else if currentPosition == 0 && be.portfolioManager.GetPortfolio().Cash >= be.positionSize {
-					// Open long position
-					qty := be.positionSize / point.Price
-					order = &Order{
-						Symbol: "BTCUSDT",
-						Qty:    qty,
-						Price:  point.Price,
-						IsBuy:  true,
-						Time:   time.Unix(point.Time/1000, 0),
-					}
-				}
-			case "SELL":
-				if currentPosition > 0 {
-					// Close long position
-					order = &Order{
-						Symbol: "BTCUSDT",
-						Qty:    currentPosition, // Close entire position
-						Price:  point.Price,
-						IsBuy:  false,
-						Time:   time.Unix(point.Time/1000, 0),
-					}
-				} else if currentPosition == 0 {
-					// Open short position
-					qty := be.positionSize / point.Price
-					order = &Order{
-						Symbol: "BTCUSDT",
-						Qty:    qty,
-						Price:  point.Price,
-						IsBuy:  false,
-						Time:   time.Unix(point.Time/1000, 0),
-					}
-				}
-			case "EXIT_LONG":
-				// Exit long position if we have one
-				if currentPosition > 0 {
-					order = &Order{
-						Symbol: "BTCUSDT",
-						Qty:    currentPosition, // Close entire position
-						Price:  point.Price,
-						IsBuy:  false,
-						Time:   time.Unix(point.Time/1000, 0),
-					}
-				}
-			case "EXIT_SHORT":
-				// Exit short position if we have one
-				if currentPosition < 0 {
-					order = &Order{
-						Symbol: "BTCUSDT",
-						Qty:    -currentPosition, // Close entire position
-						Price:  point.Price,
-						IsBuy:  true,
-						Time:   time.Unix(point.Time/1000, 0),
-					}
-				}
-			}
-		} else {
+		if strategy == nil {
 			// Default behavior - buy all points (for backward compatibility)
-			qty := be.positionSize / point.Price
-			order = &Order{
-				Symbol: "BTCUSDT",
-				Qty:    qty,
-				Price:  point.Price,
-				IsBuy:  true,
-				Time:   time.Unix(point.Time/1000, 0),
-			}
+			order := be.newOrder(be.config.PositionSize/point.Price, true, point)
+			be.report(be.exchange.Submit(order), strategy, result)
+			continue
 		}
 
-		// Execute the order if one was created
-		if order != nil {
-			trade, err := be.portfolioManager.ExecuteOrder(order)
-			if err != nil {
-				// Log error but continue
-				continue
+		signal := strategy.OnTick(point)
+		if hasSeries {
+			for name, value := range publisher.Series() {
+				result.Series[name] = append(result.Series[name], SeriesPoint{Time: point.Time, Value: value})
 			}
-			result.Trades = append(result.Trades, trade)
 		}
+		be.send(signal, point, strategy, result)
 	}
+	be.drain(strategy, result)
 
 	result.EndTime = time.Now()
 	result.FinalEquity = be.portfolioManager.GetPortfolio().Equity
+	result.SymbolPnL = be.symbolPnL(result.Trades)
+	result.TotalPnL = result.FinalEquity - be.config.InitialCash
+
+	if finisher, ok := strategy.(Finisher); ok {
+		finisher.OnEnd(result)
+	}
 
 	return result
 }
+
+// submitSignal sends the orders requested by a signal to the exchange
+func (be *BacktestEngine) submitSignal(signal Signal, point ChartPoint, strategy Strategy, result *BacktestResult) {
+	if signal.CancelAll {
+		be.exchange.CancelAll()
+	}
+	orders := be.ordersForSignal(signal, point)
+	decision := be.newDecision(signal, point, orders)
+	for _, id := range signal.Cancel {
+		if be.exchange.Cancel(id) == nil {
+			be.report([]Execution{{Order: &Order{ID: id}, Err: ErrUnknownOrder}}, strategy, result)
+		}
+	}
+	for _, replacement := range signal.Replace {
+		if replacement.Time.IsZero() {
+			replacement.Time = point.Timestamp()
+		}
+		executions := be.exchange.Replace(replacement)
+		decision.record(executions, be.exchange.find(replacement.ID) >= 0)
+		be.report(executions, strategy, result)
+	}
+	for _, order := range orders {
+		executions := be.exchange.Submit(order)
+		decision.record(executions, be.exchange.isOpen(order))
+		be.report(executions, strategy, result)
+	}
+	be.logDecision(decision)
+}
+
+// rejectSignal reports the orders and replacements requested by a warm-up signal as rejected
+func (be *BacktestEngine) rejectSignal(signal Signal, point ChartPoint, strategy Strategy) {
+	orders := be.ordersForSignal(signal, point)
+	decision := be.newDecision(signal, point, orders)
+	orders = append(orders, signal.Replace...)
+	if decision != nil {
+		decision.Warmup = true
+		for range orders {
+			decision.Rejects = append(decision.Rejects, ErrWarmup.Error())
+		}
+		be.logDecision(decision)
+	}
+
+	listener, ok := strategy.(RejectListener)
+	if !ok {
+		return
+	}
+	for _, order := range orders {
+		listener.OnReject(order, ErrWarmup)
+	}
+}
+
+// ordersForSignal returns the order implied by the signal action followed by its explicit orders.
+// Orders without a reason of their own take the signal's.
+func (be *BacktestEngine) ordersForSignal(signal Signal, point ChartPoint) []*Order {
+	var orders []*Order
+	if order := be.orderForSignal(signal, point); order != nil {
+		orders = append(orders, order)
+	}
+
+	for _, order := range signal.Orders {
+		if order.Symbol == "" {
+			order.Symbol = point.Symbol
+		}
+		if order.Time.IsZero() {
+			order.Time = point.Timestamp()
+		}
+		orders = append(orders, order)
+	}
+
+	for _, order := range orders {
+		if order.Reason == "" && order.Values == nil {
+			order.Reason, order.Values = signal.Reason, signal.Values
+		}
+	}
+	return orders
+}
+
+// report records executions in the result and notifies the strategy
+func (be *BacktestEngine) report(executions []Execution, strategy Strategy, result *BacktestResult) {
+	for _, execution := range executions {
+		if execution.Err != nil {
+			if result.Rejections == nil {
+				result.Rejections = make(map[string]int)
+			}
+			result.Rejections[rejectionReason(execution.Err)]++
+		} else {
+			result.Trades = append(result.Trades, execution.Trade)
+			result.Costs.Commission += execution.Trade.Commission
+			result.Costs.Slippage += execution.Trade.Slippage
+			result.Costs.Impact += execution.Trade.Impact
+		}
+		be.notify(execution, strategy)
+	}
+}
+
+// rejectionReason groups rejection errors for counting, dropping amounts from funding errors
+func rejectionReason(err error) string {
+	var insufficientFunds *InsufficientFundsError
+	if errors.As(err, &insufficientFunds) {
+		return "insufficient funds"
+	}
+	return err.Error()
+}
+
+// orderForSignal translates a strategy signal into an order, or nil if no action is needed
+func (be *BacktestEngine) orderForSignal(signal Signal, point ChartPoint) *Order {
+	// Check current position, including what working orders have yet to fill
+	currentPosition := be.exchange.working(point.Symbol)
+	if pos, exists := be.portfolioManager.GetPortfolio().Positions[point.Symbol]; exists {
+		currentPosition += pos.Qty
+	}
+	// A remainder left by rounding in the sum of fills counts as flat
+	if math.Abs(currentPosition) <= be.config.PositionSize/point.Price*quantityEpsilon {
+		currentPosition = 0
+	}
+
+	switch signal.Action {
+	case "BUY":
+		if currentPosition < 0 {
+			// Close short position
+			return be.newOrder(-currentPosition, true, point)
+		} else if currentPosition == 0 && be.portfolioManager.GetPortfolio().Cash >= be.config.PositionSize {
+			// Open long position
+			return be.newOrder(be.config.PositionSize/point.Price, true, point)
+		}
+	case "SELL":
+		if currentPosition > 0 {
+			// Close long position
+			return be.newOrder(currentPosition, false, point)
+		} else if currentPosition == 0 {
+			// Open short position
+			return be.newOrder(be.config.PositionSize/point.Price, false, point)
+		}
+	case "EXIT_LONG":
+		if currentPosition > 0 {
+			return be.newOrder(currentPosition, false, point)
+		}
+	case "EXIT_SHORT":
+		if currentPosition < 0 {
+			return be.newOrder(-currentPosition, true, point)
+		}
+	case "TARGET":
+		delta := signal.Target*be.config.PositionSize/point.Price - currentPosition
+		if delta > 0 {
+			return be.newOrder(delta, true, point)
+		} else if delta < 0 {
+			return be.newOrder(-delta, false, point)
+		}
+	}
+	return nil
+}
+
+// newOrder creates an order for the point symbol at the point price
+func (be *BacktestEngine) newOrder(qty float64, isBuy bool, point ChartPoint) *Order {
+	return &Order{
+		Symbol: point.Symbol,
+		Qty:    qty,
+		Price:  point.Price,
+		IsBuy:  isBuy,
+		Time:   point.Timestamp(),
+	}
+}
+
+// symbolPnL returns realized plus unrealized PnL per symbol at the last traded prices
+func (be *BacktestEngine) symbolPnL(trades []*Trade) map[string]float64 {
+	pnl := make(map[string]float64)
+	for _, trade := range trades {
+		notional := trade.Price * trade.Qty
+		if trade.IsBuy {
+			notional = -notional
+		}
+		pnl[trade.Symbol] += notional - trade.Commission
+	}
+
+	prices := be.exchange.LastPrices()
+	for symbol, position := range be.portfolioManager.GetPortfolio().Positions {
+		pnl[symbol] += position.Qty * prices[symbol]
+	}
+	return pnl
+}
+
+// timerSchedule fires the timers of a TimerListener strategy
+type timerSchedule struct {
+	listener TimerListener
+	interval time.Duration
+	next     time.Time
+}
+
+// newTimerSchedule schedules the first timer one interval after the start of data
+func newTimerSchedule(strategy Strategy, data []ChartPoint) *timerSchedule {
+	schedule := &timerSchedule{}
+	listener, ok := strategy.(TimerListener)
+	if !ok || listener.TimerInterval() <= 0 || len(data) == 0 {
+		return schedule
+	}
+	schedule.listener = listener
+	schedule.interval = listener.TimerInterval()
+	schedule.next = data[0].Timestamp().Truncate(schedule.interval).Add(schedule.interval)
+	return schedule
+}
+
+// fire calls the timers due at or before now
+func (s *timerSchedule) fire(now time.Time) {
+	for s.listener != nil && !now.Before(s.next) {
+		s.listener.OnTimer(s.next)
+		s.next = s.next.Add(s.interval)
+	}
+}
@@ -0,0 +1,314 @@
+package backtester
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Order errors reported by the exchange
+var (
+	ErrUnknownOrder     = errors.New("no resting order with this ID")
+	ErrDuplicateOrderID = errors.New("a resting order already has this ID")
+)
+
+// Execution is the outcome of an order at the exchange: a trade or a rejection
+type Execution struct {
+	Order *Order
+	Trade *Trade
+	Err   error
+}
+
+// Exchange simulates order matching against the trade stream.
+// Market orders fill immediately at the last traded price of their symbol;
+// limit orders rest until a later trade prints through their price, or trades at
+// their price and the queue model lets them fill. With a participation rate set,
+// fills are capped by traded volume and orders may fill in several trades.
+// Resting orders can be cancelled or replaced by ID.
+type Exchange struct {
+	portfolioManager *PortfolioManager
+	queue            QueueModel
+	slippage         SlippageModel            // Nil for fills at the last trade price
+	impact           *ImpactModel             // Nil without market impact
+	open             []*restingOrder          // Resting limit and waiting conditional orders in submission order
+	last             map[string]ChartPoint    // Last trade per symbol
+	clock            time.Time                // Market time of the most recent trade or order arrival
+	participation    float64                  // Fraction of each trade's quantity our orders may fill, zero for no cap
+	available        map[string]float64       // Quantity left for our orders on the last trade per symbol
+	filters          map[string]SymbolFilters // Trading rules per symbol, checked on submission
+	rejectOffGrid    bool                     // Reject orders off the tick or step instead of rounding them
+	nextID           int
+}
+
+// NewExchange creates a simulated exchange settling fills into the portfolio
+func NewExchange(portfolioManager *PortfolioManager, queue QueueModel) *Exchange {
+	return &Exchange{
+		portfolioManager: portfolioManager,
+		queue:            queue,
+		last:             make(map[string]ChartPoint),
+		available:        make(map[string]float64),
+	}
+}
+
+// Submit sends an order to the exchange, assigning an ID if it has none.
+// Marketable limit orders fill immediately at the last traded price, as do
+// conditional orders whose trigger the last price has already reached.
+func (ex *Exchange) Submit(order *Order) []Execution {
+	return ex.settle(ex.submit(order))
+}
+
+// submit places an order without applying order groups
+func (ex *Exchange) submit(order *Order) []Execution {
+	if order.ID == "" {
+		ex.nextID++
+		order.ID = fmt.Sprintf("order_%d", ex.nextID)
+	} else if ex.find(order.ID) >= 0 {
+		return []Execution{{Order: order, Err: ErrDuplicateOrderID}}
+	}
+
+	last, exists := ex.last[order.Symbol]
+	if !exists {
+		return []Execution{{Order: order, Err: fmt.Errorf("no market data for %s", order.Symbol)}}
+	}
+	if err := validateTimeInForce(order, ex.clock); err != nil {
+		return []Execution{{Order: order, Err: err}}
+	}
+	if err := ex.applyFilters(order, last.Price); err != nil {
+		return []Execution{{Order: order, Err: err}}
+	}
+	if order.conditional() {
+		if err := validateConditional(order); err != nil {
+			return []Execution{{Order: order, Err: err}}
+		}
+		resting := ex.queue.newRestingOrder(order)
+		executions, done := ex.trigger(resting, last)
+		if !done {
+			ex.open = append(ex.open, resting)
+		}
+		return executions
+	}
+	resting := ex.queue.newRestingOrder(order)
+	if order.hasLimit() && !crosses(order, last.Price) {
+		return ex.rest(resting)
+	}
+	executions, done := ex.execute(order, last.Price)
+	if !done {
+		ex.open = append(ex.open, resting)
+	}
+	return executions
+}
+
+// Match records the trade at point, expires GTD orders, triggers conditional orders,
+// fills resting orders it crosses and continues working orders
+func (ex *Exchange) Match(point ChartPoint) []Execution {
+	ex.last[point.Symbol] = point
+	ex.clock = point.Timestamp()
+	ex.available[point.Symbol] = ex.participation * point.Qty
+	if ex.slippage != nil {
+		ex.slippage.Update(point)
+	}
+	if ex.impact != nil {
+		ex.impact.Update(point)
+	}
+
+	var executions []Execution
+	filledGroups := make(map[string]bool)
+	remaining := ex.open[:0]
+	for _, resting := range ex.open {
+		order := resting.order
+		if order.OCO != "" && filledGroups[order.OCO] {
+			continue // Cancelled by a sibling filled on this trade
+		}
+		if order.expired(point.Timestamp()) {
+			executions = append(executions, Execution{Order: order, Err: ErrExpired})
+			continue
+		}
+		if order.Symbol == point.Symbol {
+			var fills []Execution
+			done := false
+			switch {
+			case order.conditional():
+				fills, done = ex.trigger(resting, point)
+			case !order.hasLimit():
+				fills, done = ex.execute(order, point.Price)
+			case ex.queue.fills(resting, point) && ex.fillable(order) > 0:
+				execution := ex.fill(order, order.Price, ex.fillable(order), Maker)
+				fills, done = []Execution{execution}, execution.Err != nil || ex.complete(order)
+			}
+			executions = append(executions, fills...)
+			filledGroups[order.OCO] = filledGroups[order.OCO] || filled(fills)
+			if done {
+				continue
+			}
+		}
+		remaining = append(remaining, resting)
+	}
+	ex.open = remaining
+	return ex.settle(executions)
+}
+
+// CancelAll removes all resting orders
+func (ex *Exchange) CancelAll() {
+	ex.open = ex.open[:0]
+}
+
+// Cancel removes the resting order with the given ID, returning it, or nil if there is none
+func (ex *Exchange) Cancel(id string) *Order {
+	i := ex.find(id)
+	if i < 0 {
+		return nil
+	}
+	order := ex.open[i].order
+	ex.open = append(ex.open[:i], ex.open[i+1:]...)
+	return order
+}
+
+// Replace cancels the resting order with the ID of replacement and submits a copy carrying
+// the replacement's price and quantity where they are set, or the unfilled quantity otherwise.
+// The new order loses its place in the queue and fills immediately if it is now marketable.
+func (ex *Exchange) Replace(replacement *Order) []Execution {
+	original := ex.Cancel(replacement.ID)
+	if original == nil {
+		return []Execution{{Order: replacement, Err: ErrUnknownOrder}}
+	}
+
+	order := *original
+	order.Qty, order.Filled = original.remaining(), 0
+	if replacement.Price > 0 {
+		order.Price = replacement.Price
+	}
+	if replacement.StopPrice > 0 {
+		order.StopPrice = replacement.StopPrice
+	}
+	if replacement.Qty > 0 {
+		order.Qty = replacement.Qty
+	}
+	if !replacement.Time.IsZero() {
+		order.Time = replacement.Time
+	}
+	if replacement.Reason != "" || replacement.Values != nil {
+		order.Reason, order.Values = replacement.Reason, replacement.Values
+	}
+	return ex.Submit(&order)
+}
+
+// find returns the index of the resting order with the given ID, or -1
+func (ex *Exchange) find(id string) int {
+	for i, resting := range ex.open {
+		if resting.order.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// isOpen reports whether the order is resting or working
+func (ex *Exchange) isOpen(order *Order) bool {
+	for _, resting := range ex.open {
+		if resting.order == order {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenOrders returns the resting orders
+func (ex *Exchange) OpenOrders() []*Order {
+	orders := make([]*Order, len(ex.open))
+	for i, resting := range ex.open {
+		orders[i] = resting.order
+	}
+	return orders
+}
+
+// LastPrices returns the last traded price per symbol
+func (ex *Exchange) LastPrices() map[string]float64 {
+	prices := make(map[string]float64, len(ex.last))
+	for symbol, point := range ex.last {
+		prices[symbol] = point.Price
+	}
+	return prices
+}
+
+// Advance moves the market time forward to t, so that orders arriving between
+// trades are stamped with their arrival time
+func (ex *Exchange) Advance(t time.Time) {
+	if t.After(ex.clock) {
+		ex.clock = t
+	}
+}
+
+// SetSlippageModel sets the model pricing orders that take liquidity
+func (ex *Exchange) SetSlippageModel(model SlippageModel) {
+	ex.slippage = model
+}
+
+// SetImpactModel sets the model charging market impact to orders that take liquidity
+func (ex *Exchange) SetImpactModel(model *ImpactModel) {
+	ex.impact = model
+}
+
+// take fills what it can of an order that crosses at the market price adjusted for slippage
+// and market impact, never worse than a limit price. Post-only orders are rejected instead.
+func (ex *Exchange) take(order *Order, price float64) Execution {
+	if order.PostOnly {
+		return Execution{Order: order, Err: ErrPostOnlyWouldCross}
+	}
+	qty := ex.fillable(order)
+	slipped := price
+	if ex.slippage != nil {
+		slipped = order.limit(ex.slippage.Price(order, qty, price))
+	}
+	impacted := slipped
+	if ex.impact != nil {
+		impacted = order.limit(ex.impact.Price(order, qty, slipped))
+	}
+
+	execution := ex.fill(order, impacted, qty, Taker)
+	if execution.Err == nil {
+		execution.Trade.Slippage = cost(order, slipped-price, qty)
+		execution.Trade.Impact = cost(order, impacted-slipped, qty)
+		if ex.impact != nil {
+			ex.impact.Fill(order, qty)
+		}
+	}
+	return execution
+}
+
+// limit caps price at the limit price of orders that have one
+func (o *Order) limit(price float64) float64 {
+	if !o.hasLimit() {
+		return price
+	}
+	if o.IsBuy {
+		return math.Min(price, o.Price)
+	}
+	return math.Max(price, o.Price)
+}
+
+// cost returns what a price worse by diff costs the order side on qty
+func cost(order *Order, diff, qty float64) float64 {
+	if order.IsBuy {
+		return diff * qty
+	}
+	return -diff * qty
+}
+
+// fill executes qty of the order at price and the current market time
+func (ex *Exchange) fill(order *Order, price, qty float64, liquidity Liquidity) Execution {
+	trade, err := ex.portfolioManager.FillAs(order, price, qty, ex.clock, liquidity)
+	if err == nil {
+		order.Filled += qty
+		ex.available[order.Symbol] -= qty
+	}
+	return Execution{Order: order, Trade: trade, Err: err}
+}
+
+// crosses reports whether a trade at price reaches the order limit
+func crosses(order *Order, price float64) bool {
+	if order.IsBuy {
+		return price <= order.Price
+	}
+	return price >= order.Price
+}
@@ -0,0 +1,191 @@
+package backtester
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// testSymbol is the symbol traded in exchange tests
+const testSymbol = "BTC"
+
+// newTestExchange creates an exchange with ample cash and no commission
+func newTestExchange(queue QueueModel) *Exchange {
+	return NewExchange(NewPortfolioManager(1e6, 0), queue)
+}
+
+// trade returns a trade of qty at price, t milliseconds after the epoch
+func trade(t int64, price, qty float64) ChartPoint {
+	return ChartPoint{Symbol: testSymbol, Time: t, Price: price, Qty: qty}
+}
+
+// trades returns the trades among executions
+func trades(executions []Execution) []*Trade {
+	var result []*Trade
+	for _, execution := range executions {
+		if execution.Err == nil {
+			result = append(result, execution.Trade)
+		}
+	}
+	return result
+}
+
+// firstErr returns the first rejection among executions, or nil
+func firstErr(executions []Execution) error {
+	for _, execution := range executions {
+		if execution.Err != nil {
+			return execution.Err
+		}
+	}
+	return nil
+}
+
+// near reports whether two quantities or prices are equal up to rounding
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestLimitOrderFills(t *testing.T) {
+	tests := []struct {
+		name      string
+		order     Order
+		prices    []float64 // Trades after submission
+		wantFill  int       // Index of the filling trade, -1 on submission, or len(prices) for none
+		wantPrice float64
+	}{
+		{"buy below market fills when a trade prints through", Order{Type: LimitOrder, Price: 99, IsBuy: true}, []float64{100, 98}, 1, 99},
+		{"buy fills on a trade at the price", Order{Type: LimitOrder, Price: 99, IsBuy: true}, []float64{99.5, 99}, 1, 99},
+		{"sell above market fills at its price", Order{Type: LimitOrder, Price: 101}, []float64{100.5, 102}, 1, 101},
+		{"marketable buy fills at the last price", Order{Type: LimitOrder, Price: 101, IsBuy: true}, nil, -1, 100},
+		{"buy is not filled above its price", Order{Type: LimitOrder, Price: 99, IsBuy: true}, []float64{99.5, 100}, 2, 0},
+		{"market order fills at the last price", Order{}, nil, -1, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := newTestExchange(QueueModel{})
+			ex.Match(trade(0, 100, 1))
+			order := tt.order
+			order.Symbol, order.Qty = testSymbol, 1
+
+			fill, filled := -1, trades(ex.Submit(&order))
+			for i, price := range tt.prices {
+				if len(filled) > 0 {
+					break
+				}
+				fill, filled = i, trades(ex.Match(trade(int64(i+1), price, 1)))
+			}
+
+			if len(filled) == 0 {
+				if tt.wantFill != len(tt.prices) {
+					t.Fatalf("order did not fill, want fill on trade %d", tt.wantFill)
+				}
+				if len(ex.OpenOrders()) != 1 {
+					t.Fatalf("unfilled order is not resting")
+				}
+				return
+			}
+			if fill != tt.wantFill || filled[0].Price != tt.wantPrice {
+				t.Fatalf("filled at %v on trade %d, want %v on trade %d", filled[0].Price, fill, tt.wantPrice, tt.wantFill)
+			}
+			if len(ex.OpenOrders()) != 0 {
+				t.Fatalf("filled order is still resting")
+			}
+		})
+	}
+}
+
+func TestQueueModels(t *testing.T) {
+	tests := []struct {
+		name     string
+		queue    QueueModel
+		trades   []ChartPoint
+		wantFill int // Index of the filling trade, or -1 for none
+	}{
+		{"touch fills on the first trade at the price", QueueModel{Ahead: 5}, []ChartPoint{{Price: 100, Qty: 0.1}}, 0},
+		{"optimistic waits for the queue ahead", QueueModel{Mode: QueueOptimistic, Ahead: 2}, []ChartPoint{{Price: 100, Qty: 1}, {Price: 100, Qty: 1.5}}, 1},
+		{"optimistic counts trades on both sides", QueueModel{Mode: QueueOptimistic, Ahead: 1}, []ChartPoint{{Price: 100, Qty: 1, IsBuyerMaker: false}}, 0},
+		{"conservative needs our quantity behind the queue", QueueModel{Mode: QueueConservative, Ahead: 2}, []ChartPoint{{Price: 100, Qty: 2, IsBuyerMaker: true}, {Price: 100, Qty: 0.5, IsBuyerMaker: true}, {Price: 100, Qty: 0.5, IsBuyerMaker: true}}, 2},
+		{"conservative ignores trades hitting the other side", QueueModel{Mode: QueueConservative}, []ChartPoint{{Price: 100, Qty: 5, IsBuyerMaker: false}}, -1},
+		{"trades through the price fill in every mode", QueueModel{Mode: QueueConservative, Ahead: 100}, []ChartPoint{{Price: 99.5, Qty: 0.1}}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := newTestExchange(tt.queue)
+			ex.Match(trade(0, 101, 1))
+			order := &Order{Symbol: testSymbol, Type: LimitOrder, Price: 100, Qty: 1, IsBuy: true}
+			if executions := ex.Submit(order); len(executions) != 0 {
+				t.Fatalf("passive order executed on submission: %v", firstErr(executions))
+			}
+
+			fill := -1
+			for i, point := range tt.trades {
+				point.Symbol, point.Time = testSymbol, int64(i+1)
+				if filled := trades(ex.Match(point)); len(filled) > 0 {
+					if filled[0].Liquidity != Maker {
+						t.Fatalf("resting fill is %s, want %s", filled[0].Liquidity, Maker)
+					}
+					fill = i
+					break
+				}
+			}
+			if fill != tt.wantFill {
+				t.Fatalf("filled on trade %d, want %d", fill, tt.wantFill)
+			}
+		})
+	}
+}
+
+func TestCancelAndReplace(t *testing.T) {
+	ex := newTestExchange(QueueModel{})
+	ex.SetParticipation(0.5)
+	ex.Match(trade(0, 101, 1))
+
+	order := &Order{ID: "bid", Symbol: testSymbol, Type: LimitOrder, Price: 100, Qty: 2, IsBuy: true}
+	ex.Submit(order)
+	if err := firstErr(ex.Submit(&Order{ID: "bid", Symbol: testSymbol, Type: LimitOrder, Price: 99, Qty: 1, IsBuy: true})); !errors.Is(err, ErrDuplicateOrderID) {
+		t.Fatalf("reusing a resting ID: got %v, want %v", err, ErrDuplicateOrderID)
+	}
+	if filled := trades(ex.Match(trade(1, 99, 2))); len(filled) != 1 || !near(filled[0].Qty, 1) {
+		t.Fatalf("want a partial fill of 1, got %v", filled)
+	}
+
+	if err := firstErr(ex.Replace(&Order{ID: "missing", Price: 98})); !errors.Is(err, ErrUnknownOrder) {
+		t.Fatalf("replacing an unknown order: got %v, want %v", err, ErrUnknownOrder)
+	}
+	if ex.Cancel("missing") != nil {
+		t.Fatalf("cancelling an unknown order returned an order")
+	}
+
+	// The replacement carries only the unfilled quantity
+	if executions := ex.Replace(&Order{ID: "bid", Price: 98}); len(executions) != 0 {
+		t.Fatalf("replacement executed on submission: %v", firstErr(executions))
+	}
+	open := ex.OpenOrders()
+	if len(open) != 1 || open[0].ID != "bid" || open[0].Price != 98 || !near(open[0].Qty, 1) || open[0].Filled != 0 {
+		t.Fatalf("unexpected book after replace: %+v", open)
+	}
+
+	if filled := trades(ex.Match(trade(2, 98.5, 10))); len(filled) != 0 {
+		t.Fatalf("replacement filled above its new price")
+	}
+	filled := trades(ex.Match(trade(3, 97, 10)))
+	if len(filled) != 1 || filled[0].Price != 98 || !near(filled[0].Qty, 1) {
+		t.Fatalf("want the rest of 1 filled at 98, got %v", filled)
+	}
+	if position := ex.portfolioManager.GetPortfolio().Positions[testSymbol]; !near(position.Qty, 2) {
+		t.Fatalf("position is %v, want 2", position.Qty)
+	}
+
+	// A replacement that crosses fills at once
+	ex.Submit(&Order{ID: "ask", Symbol: testSymbol, Type: LimitOrder, Price: 105, Qty: 1})
+	if filled := trades(ex.Replace(&Order{ID: "ask", Price: 96})); len(filled) != 1 || filled[0].Price != 97 {
+		t.Fatalf("want the marketable replacement filled at 97, got %v", filled)
+	}
+
+	ex.Submit(&Order{ID: "ask", Symbol: testSymbol, Type: LimitOrder, Price: 105, Qty: 1})
+	if cancelled := ex.Cancel("ask"); cancelled == nil || len(ex.OpenOrders()) != 0 {
+		t.Fatalf("cancel did not remove the order")
+	}
+}
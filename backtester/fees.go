@@ -0,0 +1,116 @@
+package backtester
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Liquidity tells whether a fill added liquidity to the book or took it
+type Liquidity string
+
+const (
+	Maker Liquidity = "MAKER" // Resting limit order filled by another trader
+	Taker Liquidity = "TAKER" // Order filled against the book on arrival or trigger
+)
+
+// feeWindow is the period over which traded volume sets the fee tier
+const feeWindow = 30 * 24 * time.Hour
+
+// FeeTier holds the rates that apply from a rolling 30-day traded volume upwards
+type FeeTier struct {
+	Volume float64 `json:"volume"` // Minimum 30-day volume in quote currency
+	Maker  float64 `json:"maker"`  // Rate on maker fills (0.001 = 0.1%); negative for a rebate
+	Taker  float64 `json:"taker"`  // Rate on taker fills
+}
+
+// FeeConfig describes a maker/taker fee schedule
+type FeeConfig struct {
+	Tiers       []FeeTier `json:"tiers,omitempty"`
+	Discount    float64   `json:"discount,omitempty"`     // Fraction off fees paid in the exchange's fee asset (0.25 = 25% for BNB), like the tier rates
+	PriorVolume float64   `json:"prior_volume,omitempty"` // 30-day volume already traded when the backtest starts
+}
+
+// FeeSchedule charges maker and taker rates by volume tier. Rebates are paid in full;
+// the fee-asset discount only reduces fees.
+type FeeSchedule struct {
+	config FeeConfig
+	fills  []feeFill // Fills within the last 30 days, oldest first
+	volume float64   // Volume of fills
+}
+
+// feeFill is the volume of one fill counted towards the tier
+type feeFill struct {
+	time     time.Time
+	notional float64
+}
+
+// NewFeeSchedule creates a fee schedule, or returns nil if it has no tiers
+func NewFeeSchedule(config FeeConfig) (*FeeSchedule, error) {
+	if len(config.Tiers) == 0 {
+		return nil, nil
+	}
+	if config.Discount < 0 || config.Discount > 1 {
+		return nil, fmt.Errorf("fee discount must be between 0 and 1")
+	}
+	if config.PriorVolume < 0 {
+		return nil, fmt.Errorf("prior volume must not be negative")
+	}
+
+	config.Tiers = append([]FeeTier(nil), config.Tiers...)
+	sort.Slice(config.Tiers, func(i, j int) bool { return config.Tiers[i].Volume < config.Tiers[j].Volume })
+	for i, tier := range config.Tiers {
+		if tier.Volume < 0 || tier.Taker < 0 {
+			return nil, fmt.Errorf("fee tier volumes and taker rates must not be negative")
+		}
+		if i > 0 && tier.Volume == config.Tiers[i-1].Volume {
+			return nil, fmt.Errorf("two fee tiers start at volume %g", tier.Volume)
+		}
+	}
+	return &FeeSchedule{config: config}, nil
+}
+
+// Volume returns the 30-day traded volume counted towards the tier at time at
+func (fs *FeeSchedule) Volume(at time.Time) float64 {
+	expired := 0
+	for expired < len(fs.fills) && !fs.fills[expired].time.After(at.Add(-feeWindow)) {
+		fs.volume -= fs.fills[expired].notional
+		expired++
+	}
+	fs.fills = fs.fills[expired:]
+	return fs.config.PriorVolume + fs.volume
+}
+
+// Tier returns the tier applying at time at; below the first tier the first applies
+func (fs *FeeSchedule) Tier(at time.Time) FeeTier {
+	volume := fs.Volume(at)
+	tier := fs.config.Tiers[0]
+	for _, next := range fs.config.Tiers[1:] {
+		if volume < next.Volume {
+			break
+		}
+		tier = next
+	}
+	return tier
+}
+
+// Commission returns the fee for a fill, negative for a rebate
+func (fs *FeeSchedule) Commission(price, qty float64, liquidity Liquidity, at time.Time) float64 {
+	tier := fs.Tier(at)
+	rate := tier.Taker
+	if liquidity == Maker {
+		rate = tier.Maker
+	}
+	commission := price * qty * rate
+	if commission > 0 {
+		commission *= 1 - fs.config.Discount
+	}
+	return commission
+}
+
+// Record counts a fill towards the 30-day volume
+func (fs *FeeSchedule) Record(price, qty float64, at time.Time) {
+	notional := price * qty
+	fs.fills = append(fs.fills, feeFill{time: at, notional: notional})
+	fs.volume += notional
+}
@@ -0,0 +1,92 @@
+package backtester
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFeeSchedule(t *testing.T) {
+	tiers := []FeeTier{
+		{Volume: 1000000, Maker: -0.0001, Taker: 0.0004},
+		{Volume: 0, Maker: 0.001, Taker: 0.001},
+		{Volume: 100000, Maker: 0.0005, Taker: 0.0008},
+	}
+	start := time.UnixMilli(0)
+
+	tests := []struct {
+		name      string
+		config    FeeConfig
+		fills     []float64 // Notional traded before the fill, one day apart
+		after     time.Duration
+		liquidity Liquidity
+		want      float64 // Commission on a fill of 10000
+	}{
+		{"first tier", FeeConfig{Tiers: tiers}, nil, 0, Taker, 10},
+		{"volume reaches the second tier", FeeConfig{Tiers: tiers}, []float64{60000, 50000}, 0, Taker, 8},
+		{"maker rate", FeeConfig{Tiers: tiers}, []float64{150000}, 0, Maker, 5},
+		{"prior volume counts", FeeConfig{Tiers: tiers, PriorVolume: 2000000}, nil, 0, Maker, -1},
+		{"volume older than 30 days expires", FeeConfig{Tiers: tiers}, []float64{150000}, 31 * 24 * time.Hour, Taker, 10},
+		{"discount reduces fees", FeeConfig{Tiers: tiers, Discount: 0.25}, nil, 0, Taker, 7.5},
+		{"discount leaves rebates alone", FeeConfig{Tiers: tiers, Discount: 0.25, PriorVolume: 2000000}, nil, 0, Maker, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fees, err := NewFeeSchedule(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			at := start
+			for _, notional := range tt.fills {
+				fees.Record(notional, 1, at)
+				at = at.Add(24 * time.Hour)
+			}
+			if got := fees.Commission(100, 100, tt.liquidity, at.Add(tt.after)); !near(got, tt.want) {
+				t.Fatalf("commission %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeeScheduleValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config FeeConfig
+	}{
+		{"discount above 1", FeeConfig{Tiers: []FeeTier{{Taker: 0.001}}, Discount: 25}},
+		{"negative prior volume", FeeConfig{Tiers: []FeeTier{{Taker: 0.001}}, PriorVolume: -1}},
+		{"negative taker rate", FeeConfig{Tiers: []FeeTier{{Taker: -0.001}}}},
+		{"duplicate tier volume", FeeConfig{Tiers: []FeeTier{{Volume: 10}, {Volume: 10}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewFeeSchedule(tt.config); err == nil {
+				t.Fatalf("config was accepted")
+			}
+		})
+	}
+
+	if fees, err := NewFeeSchedule(FeeConfig{}); fees != nil || err != nil {
+		t.Fatalf("want no schedule without tiers, got %v, %v", fees, err)
+	}
+}
+
+func TestExchangeChargesLiquidity(t *testing.T) {
+	pm := NewPortfolioManager(1e6, 0)
+	fees, _ := NewFeeSchedule(FeeConfig{Tiers: []FeeTier{{Maker: -0.0001, Taker: 0.001}}})
+	pm.SetFeeSchedule(fees)
+	ex := NewExchange(pm, QueueModel{})
+	ex.Match(trade(0, 100, 1))
+
+	taker := trades(ex.Submit(&Order{Symbol: testSymbol, Qty: 1, IsBuy: true}))
+	ex.Submit(&Order{Symbol: testSymbol, Type: LimitOrder, Price: 101, Qty: 1})
+	maker := trades(ex.Match(trade(1, 101, 1)))
+
+	if len(taker) != 1 || taker[0].Liquidity != Taker || !near(taker[0].Commission, 0.1) {
+		t.Fatalf("taker fill %+v, want a commission of 0.1", taker)
+	}
+	if len(maker) != 1 || maker[0].Liquidity != Maker || !near(maker[0].Commission, -0.0101) {
+		t.Fatalf("maker fill %+v, want a rebate of 0.0101", maker)
+	}
+}
@@ -0,0 +1,91 @@
+package backtester
+
+// SetParticipation caps the quantity our orders may fill on each trade at rate times the
+// trade quantity, shared by all orders of the symbol. Orders that take liquidity keep
+// working on later trades until they are filled, and IOC orders cancel what is left.
+// Zero lets every order fill completely.
+func (ex *Exchange) SetParticipation(rate float64) {
+	ex.participation = rate
+}
+
+// quantityEpsilon is the relative tolerance for rounding in sums of fill quantities
+const quantityEpsilon = 1e-9
+
+// remaining returns the quantity of the order still to fill
+func (o *Order) remaining() float64 {
+	return o.Qty - o.Filled
+}
+
+// done reports whether the order has filled completely, allowing for rounding in the sum of fills
+func (o *Order) done() bool {
+	return o.remaining() <= o.Qty*quantityEpsilon
+}
+
+// hasLimit reports whether the order never fills beyond its limit price
+func (o *Order) hasLimit() bool {
+	return o.Type == LimitOrder || o.Type == StopLimitOrder
+}
+
+// complete reports whether the order has filled, or what is left is below its symbol's
+// minimum quantity and cannot be traded
+func (ex *Exchange) complete(order *Order) bool {
+	f := ex.filters[order.Symbol]
+	return order.done() || (f.MinQty > 0 && order.remaining() < f.MinQty)
+}
+
+// fillable returns how much of the order the volume left on the last trade of its symbol allows,
+// rounded down to the symbol's step size when the volume caps it. A cap within rounding of what
+// remains fills the remainder exactly, so the position ends on the order quantity.
+func (ex *Exchange) fillable(order *Order) float64 {
+	qty := order.remaining()
+	if ex.participation > 0 && ex.available[order.Symbol] < qty {
+		capped := ex.available[order.Symbol]
+		if f := ex.filters[order.Symbol]; f.StepSize > 0 {
+			capped = floorToStep(capped, f.StepSize)
+		}
+		if qty-capped > order.Qty*quantityEpsilon {
+			qty = capped
+		}
+	}
+	return qty
+}
+
+// execute fills what it can of an order taking liquidity at price, reporting whether the order
+// is done. A fill-or-kill order is rejected unless it fills completely, and the rest of an
+// immediate-or-cancel order is cancelled; other orders keep working.
+func (ex *Exchange) execute(order *Order, price float64) ([]Execution, bool) {
+	if order.TimeInForce == FOK && ex.fillable(order) < order.remaining() {
+		return []Execution{{Order: order, Err: ErrFOKNotFilled}}, true
+	}
+
+	var executions []Execution
+	if ex.fillable(order) > 0 || order.PostOnly {
+		execution := ex.take(order, price)
+		executions = append(executions, execution)
+		if execution.Err != nil || ex.complete(order) {
+			return executions, true
+		}
+	}
+	if err := notFilledError(order); err != nil {
+		return append(executions, Execution{Order: order, Err: err}), true
+	}
+	return executions, false
+}
+
+// working returns the signed quantity still to fill of the working market and triggered
+// stop orders of a symbol, which count towards the position they will build
+func (ex *Exchange) working(symbol string) float64 {
+	qty := 0.0
+	for _, resting := range ex.open {
+		order := resting.order
+		if order.Symbol != symbol || order.hasLimit() || order.conditional() {
+			continue
+		}
+		if order.IsBuy {
+			qty += order.remaining()
+		} else {
+			qty -= order.remaining()
+		}
+	}
+	return qty
+}
@@ -0,0 +1,139 @@
+package backtester
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParticipation(t *testing.T) {
+	tests := []struct {
+		name      string
+		order     Order
+		wantFills []float64 // Quantity filled on submission and each following trade
+		wantErr   error
+	}{
+		{"market order works over several trades", Order{Qty: 3.5, IsBuy: true}, []float64{1, 1, 1, 0.5}, nil},
+		{"IOC cancels what the first trade cannot fill", Order{Qty: 3, IsBuy: true, TimeInForce: IOC}, []float64{1}, ErrIOCNotFilled},
+		{"FOK is rejected unless the trade covers it", Order{Qty: 3, IsBuy: true, TimeInForce: FOK}, nil, ErrFOKNotFilled},
+		{"FOK within the cap fills", Order{Qty: 0.5, IsBuy: true, TimeInForce: FOK}, []float64{0.5}, nil},
+		{"IOC limit fills what it can at once", Order{Type: LimitOrder, Price: 99, Qty: 2, TimeInForce: IOC}, []float64{1}, ErrIOCNotFilled},
+		{"marketable limit rests the rest", Order{Type: LimitOrder, Price: 100, Qty: 2, IsBuy: true}, []float64{1, 1}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := newTestExchange(QueueModel{})
+			ex.SetParticipation(0.5)
+			ex.portfolioManager.GetPortfolio().Positions[testSymbol] = Position{Symbol: testSymbol, Qty: 10, AvgEntryPrice: 100}
+			ex.Match(trade(0, 100, 2))
+			order := tt.order
+			order.Symbol = testSymbol
+
+			executions := ex.Submit(&order)
+			var fills []float64
+			var err error
+			for i := 1; i < 10; i++ {
+				for _, filled := range trades(executions) {
+					fills = append(fills, filled.Qty)
+				}
+				if err == nil {
+					err = firstErr(executions)
+				}
+				executions = ex.Match(trade(int64(i), 100, 2))
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if len(fills) != len(tt.wantFills) {
+				t.Fatalf("fills %v, want %v", fills, tt.wantFills)
+			}
+			for i := range fills {
+				if !near(fills[i], tt.wantFills[i]) {
+					t.Fatalf("fills %v, want %v", fills, tt.wantFills)
+				}
+			}
+			if len(ex.OpenOrders()) != 0 {
+				t.Fatalf("order still working after %v", fills)
+			}
+		})
+	}
+}
+
+func TestParticipationIsShared(t *testing.T) {
+	ex := newTestExchange(QueueModel{})
+	ex.SetParticipation(0.5)
+	ex.Match(trade(0, 101, 1))
+	first := &Order{Symbol: testSymbol, Type: LimitOrder, Price: 100, Qty: 1, IsBuy: true}
+	second := &Order{Symbol: testSymbol, Type: LimitOrder, Price: 100, Qty: 1, IsBuy: true}
+	ex.Submit(first)
+	ex.Submit(second)
+
+	filled := trades(ex.Match(trade(1, 99, 1)))
+	if len(filled) != 1 || filled[0].OrderID != first.ID || !near(filled[0].Qty, 0.5) {
+		t.Fatalf("want only the first order filled for 0.5, got %+v", filled)
+	}
+}
+
+func TestParticipationRoundsToStep(t *testing.T) {
+	ex := newTestExchange(QueueModel{})
+	ex.SetParticipation(0.5)
+	ex.SetSymbolFilters(map[string]SymbolFilters{testSymbol: {StepSize: 0.1, MinQty: 0.3}}, false)
+	ex.Match(trade(0, 100, 0.5))
+
+	// Each trade allows 0.25, rounded down to 0.2; the last 0.2 is below the minimum
+	executions := ex.Submit(&Order{Symbol: testSymbol, Qty: 1, IsBuy: true})
+	var fills []float64
+	for i := 1; i < 10; i++ {
+		for _, filled := range trades(executions) {
+			fills = append(fills, filled.Qty)
+		}
+		executions = ex.Match(trade(int64(i), 100, 0.5))
+	}
+	if len(fills) != 4 {
+		t.Fatalf("fills %v, want 4 of 0.2", fills)
+	}
+	for _, qty := range fills {
+		if qty != 0.2 {
+			t.Fatalf("fills %v, want 4 of 0.2", fills)
+		}
+	}
+	if len(ex.OpenOrders()) != 0 {
+		t.Fatalf("order with a remainder below the minimum is still working")
+	}
+}
+
+// signalScript sends the signal listed for each tick number and holds otherwise
+type signalScript map[int]string
+
+func (s signalScript) OnTick(point ChartPoint) Signal {
+	if action, ok := s[int(point.Time)]; ok {
+		return Signal{Action: action}
+	}
+	return Signal{Action: "HOLD"}
+}
+
+func TestParticipationRoundTrip(t *testing.T) {
+	// Each position takes many capped fills whose sum misses the order quantity by rounding
+	var data []ChartPoint
+	for i := 0; i < 300; i++ {
+		data = append(data, ChartPoint{Time: int64(i), Price: 97, Qty: 0.77})
+	}
+	engine := NewBacktestEngine(Config{InitialCash: 10000, PositionSize: 100, Participation: 0.1})
+	result := engine.Run(data, signalScript{0: "BUY", 100: "SELL", 200: "BUY"})
+
+	var bought, sold float64
+	for _, trade := range result.Trades {
+		if trade.Time.UnixMilli() < 200 {
+			continue
+		}
+		if trade.IsBuy {
+			bought += trade.Qty
+		} else {
+			sold += trade.Qty
+		}
+	}
+	if !near(bought, 100.0/97) || sold != 0 {
+		t.Fatalf("bought %v and sold %v after the last signal, want a new long of %v", bought, sold, 100.0/97)
+	}
+}
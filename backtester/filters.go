@@ -0,0 +1,195 @@
+package backtester
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+)
+
+// Symbol filter errors reported by the exchange
+var (
+	ErrPriceFilter = errors.New("price outside the symbol's price range")
+	ErrTickSize    = errors.New("price is not a multiple of the symbol's tick size")
+	ErrMinQty      = errors.New("quantity below the symbol's minimum")
+	ErrMaxQty      = errors.New("quantity above the symbol's maximum")
+	ErrStepSize    = errors.New("quantity is not a multiple of the symbol's step size")
+	ErrMinNotional = errors.New("order value below the symbol's minimum notional")
+	ErrMaxNotional = errors.New("order value above the symbol's maximum notional")
+)
+
+// SymbolFilters are the trading rules of a symbol; zero values are not checked
+type SymbolFilters struct {
+	TickSize    float64 `json:"tick_size,omitempty"`
+	MinPrice    float64 `json:"min_price,omitempty"`
+	MaxPrice    float64 `json:"max_price,omitempty"`
+	StepSize    float64 `json:"step_size,omitempty"`
+	MinQty      float64 `json:"min_qty,omitempty"`
+	MaxQty      float64 `json:"max_qty,omitempty"`
+	MinNotional float64 `json:"min_notional,omitempty"`
+	MaxNotional float64 `json:"max_notional,omitempty"`
+}
+
+// LoadExchangeInfo reads the symbol filters from a Binance exchangeInfo response.
+// PRICE_FILTER, LOT_SIZE, MIN_NOTIONAL and NOTIONAL filters are used; others are ignored.
+func LoadExchangeInfo(path string) (map[string]SymbolFilters, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var info struct {
+		Symbols []struct {
+			Symbol  string           `json:"symbol"`
+			Filters []map[string]any `json:"filters"`
+		} `json:"symbols"`
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("invalid exchange info: %w", err)
+	}
+
+	filters := make(map[string]SymbolFilters, len(info.Symbols))
+	for _, symbol := range info.Symbols {
+		var f SymbolFilters
+		for _, filter := range symbol.Filters {
+			fields := map[string]*float64{}
+			switch filter["filterType"] {
+			case "PRICE_FILTER":
+				fields = map[string]*float64{"tickSize": &f.TickSize, "minPrice": &f.MinPrice, "maxPrice": &f.MaxPrice}
+			case "LOT_SIZE":
+				fields = map[string]*float64{"stepSize": &f.StepSize, "minQty": &f.MinQty, "maxQty": &f.MaxQty}
+			case "MIN_NOTIONAL", "NOTIONAL":
+				fields = map[string]*float64{"minNotional": &f.MinNotional, "maxNotional": &f.MaxNotional}
+			}
+			for name, field := range fields {
+				value, err := filterValue(filter[name])
+				if err != nil {
+					return nil, fmt.Errorf("%s %s: %w", symbol.Symbol, name, err)
+				}
+				*field = value
+			}
+		}
+		filters[symbol.Symbol] = f
+	}
+	return filters, nil
+}
+
+// filterValue parses a filter field, which exchangeInfo encodes as a decimal string
+func filterValue(raw any) (float64, error) {
+	switch value := raw.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		return strconv.ParseFloat(value, 64)
+	case float64:
+		return value, nil
+	}
+	return 0, fmt.Errorf("unexpected value %v", raw)
+}
+
+// SetSymbolFilters checks new orders against per-symbol trading rules. Limit prices off
+// the tick are rounded down for buys and up for sells, and quantities off the step are
+// rounded down; with reject set such orders are rejected instead. Orders breaking a limit
+// after rounding are rejected.
+func (ex *Exchange) SetSymbolFilters(filters map[string]SymbolFilters, reject bool) {
+	ex.filters = filters
+	ex.rejectOffGrid = reject
+}
+
+// applyFilters rounds or rejects a new order according to its symbol's rules.
+// Market orders are valued at the last trade price.
+func (ex *Exchange) applyFilters(order *Order, last float64) error {
+	f, exists := ex.filters[order.Symbol]
+	if !exists {
+		return nil
+	}
+
+	if order.Price > 0 && order.hasLimit() {
+		price, err := f.price(order.Price, order.IsBuy, ex.rejectOffGrid)
+		if err != nil {
+			return err
+		}
+		order.Price = price
+	}
+	if order.StopPrice > 0 {
+		// Stop prices round up for buys and down for sells
+		stop, err := f.price(order.StopPrice, !order.IsBuy, ex.rejectOffGrid)
+		if err != nil {
+			return err
+		}
+		order.StopPrice = stop
+	}
+
+	if f.StepSize > 0 && !onStep(order.Qty, f.StepSize) {
+		if ex.rejectOffGrid {
+			return ErrStepSize
+		}
+		order.Qty = floorToStep(order.Qty, f.StepSize)
+	}
+	if order.Qty <= 0 || (f.MinQty > 0 && order.Qty < f.MinQty) {
+		return ErrMinQty
+	}
+	if f.MaxQty > 0 && order.Qty > f.MaxQty {
+		return ErrMaxQty
+	}
+
+	price := last
+	if order.hasLimit() {
+		price = order.Price
+	}
+	if f.MinNotional > 0 && order.Qty*price < f.MinNotional {
+		return ErrMinNotional
+	}
+	if f.MaxNotional > 0 && order.Qty*price > f.MaxNotional {
+		return ErrMaxNotional
+	}
+	return nil
+}
+
+// price rounds a price to the tick, down for buys and up for sells, and checks its range
+func (f SymbolFilters) price(price float64, isBuy, reject bool) (float64, error) {
+	if f.TickSize > 0 && !onStep(price, f.TickSize) {
+		if reject {
+			return 0, ErrTickSize
+		}
+		if isBuy {
+			price = floorToStep(price, f.TickSize)
+		} else {
+			price = ceilToStep(price, f.TickSize)
+		}
+	}
+	if (f.MinPrice > 0 && price < f.MinPrice) || (f.MaxPrice > 0 && price > f.MaxPrice) {
+		return 0, ErrPriceFilter
+	}
+	return price, nil
+}
+
+// stepEpsilon absorbs binary floating point error when comparing to a step
+const stepEpsilon = 1e-9
+
+// onStep reports whether value is a whole multiple of step
+func onStep(value, step float64) bool {
+	n := value / step
+	return math.Abs(n-math.Round(n)) < stepEpsilon
+}
+
+// floorToStep rounds value down to a multiple of step
+func floorToStep(value, step float64) float64 {
+	return toStep(math.Floor(value/step+stepEpsilon), step)
+}
+
+// ceilToStep rounds value up to a multiple of step
+func ceilToStep(value, step float64) float64 {
+	return toStep(math.Ceil(value/step-stepEpsilon), step)
+}
+
+// toStep returns n steps, formatted to the decimals of step to drop binary noise
+func toStep(n, step float64) float64 {
+	decimals := 0
+	for decimals < 12 && !onStep(step*math.Pow(10, float64(decimals)), 1) {
+		decimals++
+	}
+	value, _ := strconv.ParseFloat(strconv.FormatFloat(n*step, 'f', decimals, 64), 64)
+	return value
+}
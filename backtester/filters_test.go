@@ -0,0 +1,107 @@
+package backtester
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSymbolFilters(t *testing.T) {
+	filters := SymbolFilters{TickSize: 0.01, MinPrice: 1, MaxPrice: 1000, StepSize: 0.001, MinQty: 0.01, MaxQty: 10, MinNotional: 5, MaxNotional: 5000}
+
+	tests := []struct {
+		name      string
+		order     Order
+		reject    bool
+		wantErr   error
+		wantPrice float64
+		wantStop  float64
+		wantQty   float64
+	}{
+		{"buy price rounds down", Order{Type: LimitOrder, Price: 99.127, Qty: 1, IsBuy: true}, false, nil, 99.12, 0, 1},
+		{"sell price rounds up", Order{Type: LimitOrder, Price: 101.121, Qty: 1}, false, nil, 101.13, 0, 1},
+		{"sell stop rounds down", Order{Type: StopOrder, StopPrice: 90.005, Qty: 1}, false, nil, 0, 90, 1},
+		{"quantity rounds down to the step", Order{Qty: 0.30000001, IsBuy: true}, false, nil, 0, 0, 0.3},
+		{"binary noise is not off the step", Order{Qty: 0.1 + 0.2, IsBuy: true}, true, nil, 0, 0, 0.3},
+		{"off the tick in reject mode", Order{Type: LimitOrder, Price: 99.127, Qty: 1, IsBuy: true}, true, ErrTickSize, 0, 0, 0},
+		{"off the step in reject mode", Order{Qty: 0.1234, IsBuy: true}, true, ErrStepSize, 0, 0, 0},
+		{"price below the range", Order{Type: LimitOrder, Price: 0.5, Qty: 20, IsBuy: true}, false, ErrPriceFilter, 0, 0, 0},
+		{"quantity below the minimum after rounding", Order{Qty: 0.0099, IsBuy: true}, false, ErrMinQty, 0, 0, 0},
+		{"quantity above the maximum", Order{Qty: 11, IsBuy: true}, false, ErrMaxQty, 0, 0, 0},
+		{"market order valued at the last price", Order{Qty: 0.04, IsBuy: true}, false, ErrMinNotional, 0, 0, 0},
+		{"limit order valued at its price", Order{Type: LimitOrder, Price: 600, Qty: 9}, false, ErrMaxNotional, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := newTestExchange(QueueModel{})
+			ex.SetSymbolFilters(map[string]SymbolFilters{testSymbol: filters}, tt.reject)
+			ex.Match(trade(0, 100, 1))
+			order := tt.order
+			order.Symbol = testSymbol
+
+			err := ex.applyFilters(&order, 100)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if order.Price != tt.wantPrice || order.StopPrice != tt.wantStop || order.Qty != tt.wantQty {
+				t.Fatalf("got price %v stop %v qty %v, want %v %v %v", order.Price, order.StopPrice, order.Qty, tt.wantPrice, tt.wantStop, tt.wantQty)
+			}
+		})
+	}
+}
+
+func TestStepRounding(t *testing.T) {
+	tests := []struct {
+		value, step float64
+		floor, ceil float64
+	}{
+		{0.30000001, 0.1, 0.3, 0.4},
+		{0.1 + 0.2, 0.1, 0.3, 0.3},
+		{99.127, 0.01, 99.12, 99.13},
+		{12345, 100, 12300, 12400},
+		{0.00012345, 0.00000001, 0.00012345, 0.00012345},
+	}
+
+	for _, tt := range tests {
+		if got := floorToStep(tt.value, tt.step); got != tt.floor {
+			t.Errorf("floorToStep(%v, %v) = %v, want %v", tt.value, tt.step, got, tt.floor)
+		}
+		if got := ceilToStep(tt.value, tt.step); got != tt.ceil {
+			t.Errorf("ceilToStep(%v, %v) = %v, want %v", tt.value, tt.step, got, tt.ceil)
+		}
+	}
+}
+
+func TestLoadExchangeInfo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exchangeInfo.json")
+	info := `{"symbols": [{"symbol": "BTCUSDT", "filters": [
+		{"filterType": "PRICE_FILTER", "minPrice": "0.01", "maxPrice": "1000000.00", "tickSize": "0.01"},
+		{"filterType": "LOT_SIZE", "minQty": "0.00001", "maxQty": "9000.0", "stepSize": "0.00001"},
+		{"filterType": "NOTIONAL", "minNotional": "5.0", "maxNotional": "9000000.0"},
+		{"filterType": "ICEBERG_PARTS", "limit": 10}
+	]}]}`
+	if err := os.WriteFile(path, []byte(info), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	filters, err := LoadExchangeInfo(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SymbolFilters{TickSize: 0.01, MinPrice: 0.01, MaxPrice: 1000000, StepSize: 0.00001, MinQty: 0.00001, MaxQty: 9000, MinNotional: 5, MaxNotional: 9000000}
+	if got := filters["BTCUSDT"]; got != want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"symbols": [{"symbol": "X", "filters": [{"filterType": "LOT_SIZE", "stepSize": "a"}]}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadExchangeInfo(path); err == nil {
+		t.Fatalf("invalid filter value was accepted")
+	}
+}
@@ -0,0 +1,142 @@
+package backtester
+
+import "errors"
+
+// NewBracket attaches a take-profit limit order and a stop-loss stop order to an entry.
+// Both exits are placed for the filled quantity once the entry fills, and grow with
+// later partial fills of the entry. A fill on either cancels the other.
+func NewBracket(entry *Order, takeProfit, stopLoss float64) *Order {
+	entry.Bracket = []*Order{
+		{Type: LimitOrder, Price: takeProfit, Reason: "take profit"},
+		{Type: StopOrder, StopPrice: stopLoss, Reason: "stop loss"},
+	}
+	return entry
+}
+
+// settle applies order groups after executions: a fill cancels the rest of its OCO group,
+// and a filled entry places its bracket legs. Groups are cancelled before legs are placed,
+// so an entry filling on the same trade as one of its legs gets fresh legs. Executions of
+// the legs are appended and settled in turn.
+func (ex *Exchange) settle(executions []Execution) []Execution {
+	for start := 0; start < len(executions); {
+		batch := executions[start:]
+		start = len(executions)
+		for _, execution := range batch {
+			if execution.Err == nil && execution.Order.OCO != "" {
+				ex.cancelGroup(execution.Order)
+			}
+		}
+		for _, execution := range batch {
+			if execution.Err == nil && len(execution.Order.Bracket) > 0 {
+				executions = append(executions, ex.placeBracket(execution.Order, execution.Trade)...)
+			}
+		}
+	}
+	return executions
+}
+
+// placeBracket submits the exit legs of a filled entry as one OCO group,
+// stopping early if a leg fills on submission. Later partial fills of the entry
+// add their quantity to the legs still working, or place new copies of the legs
+// once the group is gone. Fills too small for the symbol's minimum quantity or
+// notional are held back until later fills make the legs large enough.
+func (ex *Exchange) placeBracket(entry *Order, trade *Trade) []Execution {
+	qty := trade.Qty + entry.unplaced
+	entry.unplaced = 0
+	legs := entry.Bracket
+	if entry.Filled > qty {
+		group := "bracket_" + entry.ID
+		if ex.growGroup(group, qty) {
+			return nil
+		}
+		legs = make([]*Order, len(entry.Bracket))
+		for i, leg := range entry.Bracket {
+			copied := *leg
+			copied.ID, copied.Qty, copied.Filled, copied.TriggerTime = "", 0, 0, nil
+			legs[i] = &copied
+		}
+	}
+
+	for _, leg := range legs {
+		leg.Symbol = entry.Symbol
+		leg.IsBuy = !entry.IsBuy
+		leg.Time = trade.Time
+		if leg.OCO == "" {
+			leg.OCO = "bracket_" + entry.ID
+		}
+		if leg.Reason == "" && leg.Values == nil {
+			leg.Reason, leg.Values = entry.Reason, entry.Values
+		}
+		if ex.tooSmall(leg, qty) {
+			entry.unplaced = qty
+			return nil
+		}
+	}
+
+	var executions []Execution
+	for _, leg := range legs {
+		if leg.Qty <= 0 {
+			leg.Qty = qty
+		}
+		legExecutions := ex.submit(leg)
+		executions = append(executions, legExecutions...)
+		if filled(legExecutions) {
+			break
+		}
+	}
+	return executions
+}
+
+// tooSmall reports whether an order would be rejected for its symbol's minimum quantity or
+// notional, sized at qty unless its quantity is set
+func (ex *Exchange) tooSmall(order *Order, qty float64) bool {
+	check := *order
+	if check.Qty <= 0 {
+		check.Qty = qty
+	}
+	err := ex.applyFilters(&check, ex.last[order.Symbol].Price)
+	return errors.Is(err, ErrMinQty) || errors.Is(err, ErrMinNotional)
+}
+
+// filled reports whether any of the executions is a fill
+func filled(executions []Execution) bool {
+	for _, execution := range executions {
+		if execution.Err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// growGroup adds qty to the working orders of an OCO group, reporting whether there were any.
+// The enlarged orders must pass the symbol filters; if one does not, none is grown.
+func (ex *Exchange) growGroup(group string, qty float64) bool {
+	grown := make(map[*Order]float64)
+	for _, resting := range ex.open {
+		if resting.order.OCO != group {
+			continue
+		}
+		order := *resting.order
+		order.Qty += qty
+		if err := ex.applyFilters(&order, ex.last[order.Symbol].Price); err != nil {
+			return false
+		}
+		grown[resting.order] = order.Qty
+	}
+	for order, qty := range grown {
+		order.Qty = qty
+	}
+	return len(grown) > 0
+}
+
+// cancelGroup removes the other resting orders of the OCO group of a filled order,
+// which keeps working if it filled only in part
+func (ex *Exchange) cancelGroup(filled *Order) {
+	remaining := ex.open[:0]
+	for _, resting := range ex.open {
+		if resting.order.OCO != filled.OCO || resting.order == filled {
+			remaining = append(remaining, resting)
+		}
+	}
+	ex.open = remaining
+}
@@ -0,0 +1,148 @@
+package backtester
+
+import "testing"
+
+func TestBracket(t *testing.T) {
+	tests := []struct {
+		name       string
+		prices     []float64 // Trades after the entry fills at 100
+		wantReason string
+		wantPrice  float64
+	}{
+		{"take profit", []float64{103, 105.5}, "take profit", 105},
+		{"stop loss", []float64{97, 94}, "stop loss", 94},
+		{"neither", []float64{101, 99}, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := newTestExchange(QueueModel{})
+			ex.Match(trade(0, 100, 1))
+			entry := NewBracket(&Order{Symbol: testSymbol, Qty: 1, IsBuy: true}, 105, 95)
+			if filled := trades(ex.Submit(entry)); len(filled) != 1 {
+				t.Fatalf("entry did not fill")
+			}
+			if open := ex.OpenOrders(); len(open) != 2 || open[0].OCO != open[1].OCO || open[0].IsBuy {
+				t.Fatalf("want two sell legs in one group, got %+v", open)
+			}
+
+			var exit *Trade
+			for i, price := range tt.prices {
+				if filled := trades(ex.Match(trade(int64(i+1), price, 1))); len(filled) > 0 {
+					exit = filled[0]
+					break
+				}
+			}
+			if tt.wantReason == "" {
+				if exit != nil || len(ex.OpenOrders()) != 2 {
+					t.Fatalf("bracket exited without reaching a leg")
+				}
+				return
+			}
+			if exit == nil || exit.Reason != tt.wantReason || exit.Price != tt.wantPrice {
+				t.Fatalf("got exit %+v, want %s at %v", exit, tt.wantReason, tt.wantPrice)
+			}
+			if len(ex.OpenOrders()) != 0 {
+				t.Fatalf("the other leg was not cancelled")
+			}
+		})
+	}
+}
+
+func TestBracketGrowsWithPartialFills(t *testing.T) {
+	ex := newTestExchange(QueueModel{})
+	ex.SetParticipation(0.5)
+	ex.Match(trade(0, 100, 2))
+	entry := NewBracket(&Order{Symbol: testSymbol, Qty: 2, IsBuy: true}, 105, 95)
+
+	ex.Submit(entry)
+	legQty := func() []float64 {
+		var qty []float64
+		for _, order := range ex.OpenOrders() {
+			if order != entry {
+				qty = append(qty, order.Qty)
+			}
+		}
+		return qty
+	}
+	if qty := legQty(); len(qty) != 2 || !near(qty[0], 1) || !near(qty[1], 1) {
+		t.Fatalf("legs after the first fill: %v, want 1 each", qty)
+	}
+
+	ex.Match(trade(1, 100, 2))
+	if qty := legQty(); len(qty) != 2 || !near(qty[0], 2) || !near(qty[1], 2) {
+		t.Fatalf("legs after the second fill: %v, want 2 each", qty)
+	}
+
+	filled := trades(ex.Match(trade(2, 106, 10)))
+	if len(filled) != 1 || filled[0].Reason != "take profit" || !near(filled[0].Qty, 2) {
+		t.Fatalf("want the take profit to close 2, got %+v", filled)
+	}
+	if len(ex.OpenOrders()) != 0 {
+		t.Fatalf("stop loss was not cancelled")
+	}
+}
+
+func TestBracketLegFillsDuringEntry(t *testing.T) {
+	ex := newTestExchange(QueueModel{})
+	ex.SetParticipation(0.5)
+	ex.Match(trade(0, 100, 2))
+	entry := NewBracket(&Order{Symbol: testSymbol, Qty: 2, IsBuy: true}, 105, 95)
+	ex.Submit(entry)
+
+	// The take profit closes the first part while the entry keeps working
+	if filled := trades(ex.Match(trade(1, 106, 4))); len(filled) != 2 {
+		t.Fatalf("want the entry rest and the take profit filled, got %+v", filled)
+	}
+
+	// The rest of the entry gets a new pair of legs
+	var legs []*Order
+	for _, order := range ex.OpenOrders() {
+		legs = append(legs, order)
+	}
+	if len(legs) != 2 || !near(legs[0].Qty, 1) || legs[0].OCO != "bracket_"+entry.ID {
+		t.Fatalf("want new legs for 1, got %+v", legs)
+	}
+}
+
+func TestBracketHoldsBackLegsBelowMinQty(t *testing.T) {
+	ex := newTestExchange(QueueModel{})
+	ex.SetParticipation(0.5)
+	ex.SetSymbolFilters(map[string]SymbolFilters{testSymbol: {StepSize: 0.1, MinQty: 0.3}}, false)
+	ex.Match(trade(0, 100, 0.5))
+	entry := NewBracket(&Order{Symbol: testSymbol, Qty: 1, IsBuy: true}, 105, 95)
+
+	// 0.2 is below the minimum, so no legs yet and no rejections
+	executions := ex.Submit(entry)
+	if err := firstErr(executions); err != nil || len(ex.OpenOrders()) != 1 {
+		t.Fatalf("legs were placed or rejected for 0.2: %v", err)
+	}
+
+	executions = ex.Match(trade(1, 100, 0.5))
+	if err := firstErr(executions); err != nil {
+		t.Fatal(err)
+	}
+	open := ex.OpenOrders()
+	if len(open) != 3 || !near(open[1].Qty, 0.4) {
+		t.Fatalf("want legs for 0.4, got %+v", open)
+	}
+}
+
+func TestOCO(t *testing.T) {
+	ex := newTestExchange(QueueModel{})
+	ex.Match(trade(0, 100, 1))
+	buy := &Order{Symbol: testSymbol, Type: LimitOrder, Price: 95, Qty: 1, IsBuy: true, OCO: "range"}
+	sell := &Order{Symbol: testSymbol, Type: LimitOrder, Price: 105, Qty: 1, OCO: "range"}
+	other := &Order{Symbol: testSymbol, Type: LimitOrder, Price: 90, Qty: 1, IsBuy: true}
+	for _, order := range []*Order{buy, sell, other} {
+		ex.Submit(order)
+	}
+
+	filled := trades(ex.Match(trade(1, 94, 1)))
+	if len(filled) != 1 || filled[0].OrderID != buy.ID {
+		t.Fatalf("want the buy filled, got %+v", filled)
+	}
+	if open := ex.OpenOrders(); len(open) != 1 || open[0] != other {
+		t.Fatalf("want only the order outside the group left, got %+v", open)
+	}
+}
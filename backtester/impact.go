@@ -0,0 +1,122 @@
+package backtester
+
+import (
+	"fmt"
+	"hft-backtester/indicators"
+	"math"
+)
+
+// ImpactConfig sets the coefficients of the square-root market impact model
+type ImpactConfig struct {
+	Temporary float64 `json:"temporary,omitempty"`    // Temporary impact per unit of volatility times the square root of participation
+	Permanent float64 `json:"permanent,omitempty"`    // Permanent impact per unit of volatility times participation
+	Window    int     `json:"window,omitempty"`       // Trades used to estimate volatility and volume (default 100)
+	HalfLife  int64   `json:"half_life_ms,omitempty"` // Milliseconds for permanent impact to decay by half (default 60000)
+}
+
+// ImpactModel prices the market impact of orders taking liquidity. Over a window of
+// recent trades with volatility sigma and volume V, filling q moves the price against
+// the order by Temporary*sigma*sqrt(q/V) for that fill only, and shifts the prices of
+// later fills of the symbol by Permanent*sigma*q/V in the direction of the order.
+// That shift decays exponentially with trade time, halving every HalfLife, as the
+// market absorbs our flow. No impact is charged until a full window of trades has
+// been seen.
+type ImpactModel struct {
+	temporary float64
+	permanent float64
+	window    int
+	halfLife  float64 // Milliseconds
+	symbols   map[string]*impactEstimate
+}
+
+// impactEstimate tracks the volatility, volume and accumulated permanent impact of one symbol
+type impactEstimate struct {
+	lastPrice float64
+	returns   *indicators.RollingVariance
+	volume    *indicators.Sum
+	shift     float64 // Relative price shift left by our past fills
+	lastTime  int64   // Time of the last trade, to which shift has been decayed
+}
+
+// NewImpactModel creates an impact model, or returns nil if both coefficients are zero
+func NewImpactModel(config ImpactConfig) (*ImpactModel, error) {
+	if config.Temporary < 0 || config.Permanent < 0 || config.HalfLife < 0 {
+		return nil, fmt.Errorf("impact coefficients and half-life must not be negative")
+	}
+	if config.Temporary == 0 && config.Permanent == 0 {
+		return nil, nil
+	}
+	window := config.Window
+	if window <= 0 {
+		window = 100
+	}
+	halfLife := config.HalfLife
+	if halfLife == 0 {
+		halfLife = 60000
+	}
+	return &ImpactModel{
+		temporary: config.Temporary,
+		permanent: config.Permanent,
+		window:    window,
+		halfLife:  float64(halfLife),
+		symbols:   make(map[string]*impactEstimate),
+	}, nil
+}
+
+// Update is called with every trade before orders are matched against it
+func (m *ImpactModel) Update(point ChartPoint) {
+	estimate, exists := m.symbols[point.Symbol]
+	if !exists {
+		estimate = &impactEstimate{
+			returns: indicators.NewRollingVariance(m.window),
+			volume:  indicators.NewSum(m.window),
+		}
+		m.symbols[point.Symbol] = estimate
+	}
+	if estimate.shift != 0 && point.Time > estimate.lastTime {
+		estimate.shift *= math.Exp2(-float64(point.Time-estimate.lastTime) / m.halfLife)
+	}
+	estimate.lastTime = point.Time
+	if estimate.lastPrice > 0 && point.Price > 0 {
+		estimate.returns.Update(math.Log(point.Price / estimate.lastPrice))
+	}
+	estimate.lastPrice = point.Price
+	estimate.volume.Update(point.Qty)
+}
+
+// participation returns the volatility over the window and the share of its volume that qty makes up
+func (m *ImpactModel) participation(symbol string, qty float64) (float64, float64, bool) {
+	estimate, exists := m.symbols[symbol]
+	if !exists || !estimate.returns.Ready() || !estimate.volume.Ready() || estimate.volume.Value() <= 0 {
+		return 0, 0, false
+	}
+	sigma := estimate.returns.StdDev() * math.Sqrt(float64(m.window))
+	return sigma, qty / estimate.volume.Value(), true
+}
+
+// Price returns the fill price for qty of order after permanent and temporary impact
+func (m *ImpactModel) Price(order *Order, qty, price float64) float64 {
+	estimate, exists := m.symbols[order.Symbol]
+	if !exists {
+		return price
+	}
+	price *= 1 + estimate.shift
+	sigma, share, ok := m.participation(order.Symbol, qty)
+	if !ok {
+		return price
+	}
+	return slip(order, price, m.temporary*sigma*math.Sqrt(share))
+}
+
+// Fill leaves the permanent impact of a fill of qty on later fills of the symbol
+func (m *ImpactModel) Fill(order *Order, qty float64) {
+	sigma, share, ok := m.participation(order.Symbol, qty)
+	if !ok {
+		return
+	}
+	if order.IsBuy {
+		m.symbols[order.Symbol].shift += m.permanent * sigma * share
+	} else {
+		m.symbols[order.Symbol].shift -= m.permanent * sigma * share
+	}
+}
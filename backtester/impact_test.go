@@ -0,0 +1,60 @@
+package backtester
+
+import (
+	"math"
+	"testing"
+)
+
+func TestImpactModel(t *testing.T) {
+	model, err := NewImpactModel(ImpactConfig{Temporary: 1, Permanent: 1, Window: 20, HalfLife: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buy := &Order{Symbol: testSymbol, IsBuy: true}
+	sell := &Order{Symbol: testSymbol}
+
+	update := func(ms int64, price float64) {
+		model.Update(ChartPoint{Symbol: testSymbol, Time: ms, Price: price, Qty: 1})
+	}
+	update(0, 100)
+	if price := model.Price(buy, 5, 100); price != 100 {
+		t.Fatalf("impact charged before the window filled: %v", price)
+	}
+	for ms := int64(1); ms < 30; ms++ {
+		update(ms, 100+math.Sin(float64(ms)))
+	}
+
+	// Temporary impact moves the price against the order
+	if price := model.Price(buy, 5, 100); price <= 100 {
+		t.Fatalf("buy price %v, want above 100", price)
+	}
+	if price := model.Price(sell, 5, 100); price >= 100 {
+		t.Fatalf("sell price %v, want below 100", price)
+	}
+
+	// Permanent impact shifts later prices and halves every half-life
+	model.Fill(buy, 5)
+	shift := model.symbols[testSymbol].shift
+	if shift <= 0 {
+		t.Fatalf("buy left shift %v, want positive", shift)
+	}
+	update(1029, 100)
+	if got := model.symbols[testSymbol].shift; math.Abs(got-shift/2) > 1e-12 {
+		t.Fatalf("shift after one half-life %v, want %v", got, shift/2)
+	}
+	update(3029, 100)
+	if got := model.symbols[testSymbol].shift; math.Abs(got-shift/8) > 1e-12 {
+		t.Fatalf("shift after three half-lives %v, want %v", got, shift/8)
+	}
+}
+
+func TestImpactConfig(t *testing.T) {
+	if model, err := NewImpactModel(ImpactConfig{}); model != nil || err != nil {
+		t.Fatalf("want no model without coefficients, got %v, %v", model, err)
+	}
+	for _, config := range []ImpactConfig{{Temporary: -1}, {Permanent: -1}, {Permanent: 1, HalfLife: -1}} {
+		if _, err := NewImpactModel(config); err == nil {
+			t.Fatalf("%+v was accepted", config)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package backtester
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// ErrNotArrived rejects orders still on their way to the exchange when the data ends
+var ErrNotArrived = errors.New("order had not reached the exchange by the end of the data")
+
+// LatencyConfig describes the delays between the strategy and the exchange.
+// Orders reach the exchange OrderMs after the event that produced them and
+// fills and rejections reach the strategy ReportMs after they happen.
+type LatencyConfig struct {
+	OrderMs      float64 `json:"order_ms,omitempty"`     // Mean strategy-to-exchange delay
+	ReportMs     float64 `json:"report_ms,omitempty"`    // Mean exchange-to-strategy delay
+	Distribution string  `json:"distribution,omitempty"` // Empty or fixed, uniform, exponential or lognormal
+	Jitter       float64 `json:"jitter,omitempty"`       // uniform: half-width in ms; lognormal: sigma of the log
+	Seed         int64   `json:"seed,omitempty"`         // Seed for sampled delays
+}
+
+// Validate checks the latency settings
+func (c LatencyConfig) Validate() error {
+	if c.OrderMs < 0 || c.ReportMs < 0 || c.Jitter < 0 {
+		return fmt.Errorf("latency parameters must not be negative")
+	}
+	switch c.Distribution {
+	case "", "fixed", "uniform", "exponential", "lognormal":
+		return nil
+	}
+	return fmt.Errorf("unknown latency distribution %q", c.Distribution)
+}
+
+// latencySampler draws delays from the configured distribution
+type latencySampler struct {
+	config LatencyConfig
+	rng    *rand.Rand
+}
+
+// newLatencySampler returns a sampler, or nil when both delays are zero
+func newLatencySampler(config LatencyConfig) *latencySampler {
+	if config.OrderMs <= 0 && config.ReportMs <= 0 {
+		return nil
+	}
+	return &latencySampler{config: config, rng: rand.New(rand.NewSource(config.Seed))}
+}
+
+// sample draws a delay with the given mean in milliseconds
+func (l *latencySampler) sample(mean float64) time.Duration {
+	if mean <= 0 {
+		return 0
+	}
+
+	ms := mean
+	switch l.config.Distribution {
+	case "uniform":
+		ms = math.Max(mean+(2*l.rng.Float64()-1)*l.config.Jitter, 0)
+	case "exponential":
+		ms = l.rng.ExpFloat64() * mean
+	case "lognormal":
+		sigma := l.config.Jitter
+		ms = mean * math.Exp(sigma*l.rng.NormFloat64()-sigma*sigma/2)
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// pendingSignal is a signal on its way to the exchange
+type pendingSignal struct {
+	at     time.Time
+	signal Signal
+	point  ChartPoint
+}
+
+// pendingReport is an execution on its way back to the strategy
+type pendingReport struct {
+	at        time.Time
+	execution Execution
+}
+
+// send passes a signal to the exchange, after the order latency if one is configured.
+// Delayed signals are translated into orders when they arrive, against the position then.
+func (be *BacktestEngine) send(signal Signal, point ChartPoint, strategy Strategy, result *BacktestResult) {
+	var delay time.Duration
+	if be.latency != nil {
+		delay = be.latency.sample(be.latency.config.OrderMs)
+	}
+	if delay == 0 {
+		be.submitSignal(signal, point, strategy, result)
+		return
+	}
+	if signal.idle() {
+		return
+	}
+
+	pending := pendingSignal{at: point.Timestamp().Add(delay), signal: signal, point: point}
+	i := sort.Search(len(be.outbox), func(i int) bool { return be.outbox[i].at.After(pending.at) })
+	be.outbox = append(be.outbox, pendingSignal{})
+	copy(be.outbox[i+1:], be.outbox[i:])
+	be.outbox[i] = pending
+}
+
+// notify tells the strategy about an execution, after the report latency if one is configured
+func (be *BacktestEngine) notify(execution Execution, strategy Strategy) {
+	var delay time.Duration
+	if be.latency != nil {
+		delay = be.latency.sample(be.latency.config.ReportMs)
+	}
+	if delay == 0 {
+		deliverExecution(execution, strategy)
+		return
+	}
+
+	pending := pendingReport{at: be.exchange.clock.Add(delay), execution: execution}
+	i := sort.Search(len(be.inbox), func(i int) bool { return be.inbox[i].at.After(pending.at) })
+	be.inbox = append(be.inbox, pendingReport{})
+	copy(be.inbox[i+1:], be.inbox[i:])
+	be.inbox[i] = pending
+}
+
+// deliver processes the signals and reports due by until in time order, reports first on ties
+func (be *BacktestEngine) deliver(until time.Time, strategy Strategy, result *BacktestResult) {
+	for {
+		signalDue := len(be.outbox) > 0 && !be.outbox[0].at.After(until)
+		reportDue := len(be.inbox) > 0 && !be.inbox[0].at.After(until)
+
+		switch {
+		case reportDue && (!signalDue || !be.inbox[0].at.After(be.outbox[0].at)):
+			report := be.inbox[0]
+			be.inbox = be.inbox[1:]
+			deliverExecution(report.execution, strategy)
+		case signalDue:
+			pending := be.outbox[0]
+			be.outbox = be.outbox[1:]
+			be.exchange.Advance(pending.at)
+			be.submitSignal(pending.signal, pending.point, strategy, result)
+		default:
+			return
+		}
+	}
+}
+
+// drain rejects the signals that never reached the exchange and delivers all outstanding reports
+func (be *BacktestEngine) drain(strategy Strategy, result *BacktestResult) {
+	for _, pending := range be.outbox {
+		orders := append(be.ordersForSignal(pending.signal, pending.point), pending.signal.Replace...)
+		for _, order := range orders {
+			be.report([]Execution{{Order: order, Err: ErrNotArrived}}, strategy, result)
+		}
+	}
+	be.outbox = nil
+
+	for _, report := range be.inbox {
+		deliverExecution(report.execution, strategy)
+	}
+	be.inbox = nil
+}
+
+// deliverExecution calls the fill or reject listener of the strategy
+func deliverExecution(execution Execution, strategy Strategy) {
+	if execution.Err != nil {
+		if listener, ok := strategy.(RejectListener); ok {
+			listener.OnReject(execution.Order, execution.Err)
+		}
+		return
+	}
+	if listener, ok := strategy.(FillListener); ok {
+		listener.OnFill(execution.Trade)
+	}
+}
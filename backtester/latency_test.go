@@ -0,0 +1,110 @@
+package backtester
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// scriptedStrategy buys on its first tick and records what reaches it
+type scriptedStrategy struct {
+	ticks    int
+	fills    []int // Ticks seen when each fill was reported
+	rejected []error
+}
+
+func (s *scriptedStrategy) OnTick(point ChartPoint) Signal {
+	s.ticks++
+	if s.ticks == 1 {
+		return Signal{Action: "BUY"}
+	}
+	return Signal{Action: "HOLD"}
+}
+
+func (s *scriptedStrategy) OnFill(trade *Trade) {
+	s.fills = append(s.fills, s.ticks)
+}
+
+func (s *scriptedStrategy) OnReject(order *Order, err error) {
+	s.rejected = append(s.rejected, err)
+}
+
+func TestLatency(t *testing.T) {
+	data := []ChartPoint{{Time: 0, Price: 100}, {Time: 50, Price: 101}, {Time: 150, Price: 102}, {Time: 400, Price: 103}}
+
+	tests := []struct {
+		name       string
+		latency    LatencyConfig
+		wantPrice  float64 // Fill price, zero if the order never arrives
+		wantTime   int64
+		wantReport int // Ticks seen by the strategy when the fill is reported
+	}{
+		{"no latency fills at the signal price", LatencyConfig{}, 100, 0, 1},
+		{"order latency fills at the last price on arrival", LatencyConfig{OrderMs: 100}, 101, 100, 2},
+		{"report latency delays the fill notice", LatencyConfig{ReportMs: 200}, 100, 0, 3},
+		{"both delays add up", LatencyConfig{OrderMs: 100, ReportMs: 200}, 101, 100, 3},
+		{"orders in flight at the end are rejected", LatencyConfig{OrderMs: 1000}, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewBacktestEngine(Config{InitialCash: 10000, PositionSize: 100, Latency: tt.latency})
+			strategy := &scriptedStrategy{}
+			result := engine.Run(data, strategy)
+
+			if tt.wantPrice == 0 {
+				if len(result.Trades) != 0 || len(strategy.rejected) != 1 || !errors.Is(strategy.rejected[0], ErrNotArrived) {
+					t.Fatalf("want the order rejected as not arrived, got %d trades and %v", len(result.Trades), strategy.rejected)
+				}
+				return
+			}
+			if len(result.Trades) != 1 {
+				t.Fatalf("want 1 trade, got %d", len(result.Trades))
+			}
+			trade := result.Trades[0]
+			if trade.Price != tt.wantPrice || !trade.Time.Equal(time.UnixMilli(tt.wantTime)) {
+				t.Fatalf("filled at %v at %v, want %v at %dms", trade.Price, trade.Time.UnixMilli(), tt.wantPrice, tt.wantTime)
+			}
+			if len(strategy.fills) != 1 || strategy.fills[0] != tt.wantReport {
+				t.Fatalf("fill reported after tick %v, want %d", strategy.fills, tt.wantReport)
+			}
+		})
+	}
+}
+
+func TestLatencySampling(t *testing.T) {
+	tests := []struct {
+		config LatencyConfig
+		min    time.Duration
+		max    time.Duration
+	}{
+		{LatencyConfig{OrderMs: 10}, 10 * time.Millisecond, 10 * time.Millisecond},
+		{LatencyConfig{OrderMs: 10, Distribution: "uniform", Jitter: 5}, 5 * time.Millisecond, 15 * time.Millisecond},
+		{LatencyConfig{OrderMs: 10, Distribution: "exponential"}, 0, time.Hour},
+		{LatencyConfig{OrderMs: 10, Distribution: "lognormal", Jitter: 0.5}, 0, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if err := tt.config.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		sampler := newLatencySampler(tt.config)
+		again := newLatencySampler(tt.config)
+		for i := 0; i < 100; i++ {
+			delay := sampler.sample(tt.config.OrderMs)
+			if delay < tt.min || delay > tt.max {
+				t.Fatalf("%s delay %v outside [%v, %v]", tt.config.Distribution, delay, tt.min, tt.max)
+			}
+			if repeat := again.sample(tt.config.OrderMs); repeat != delay {
+				t.Fatalf("%s delays differ for the same seed", tt.config.Distribution)
+			}
+		}
+	}
+
+	if err := (LatencyConfig{Distribution: "gamma"}).Validate(); err == nil {
+		t.Fatalf("unknown distribution was accepted")
+	}
+	if newLatencySampler(LatencyConfig{}) != nil {
+		t.Fatalf("want no sampler without delays")
+	}
+}
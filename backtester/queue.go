@@ -0,0 +1,73 @@
+package backtester
+
+import "fmt"
+
+// Queue modes
+const (
+	QueueTouch        = ""             // Fill as soon as a trade prints at the limit price
+	QueueConservative = "conservative" // Fill once our own quantity has traded behind the queue, counting only trades hitting our side
+	QueueOptimistic   = "optimistic"   // Fill once the queue ahead has traded, counting all trades at our price
+)
+
+// QueueModel decides when resting limit orders fill at their price.
+// Trades through the price fill the order in every mode.
+type QueueModel struct {
+	Mode  string  `json:"mode,omitempty"`
+	Ahead float64 `json:"ahead,omitempty"` // Quantity assumed ahead of each order when it joins the book
+}
+
+// Validate checks the queue mode
+func (q QueueModel) Validate() error {
+	switch q.Mode {
+	case QueueTouch, QueueConservative, QueueOptimistic:
+	default:
+		return fmt.Errorf("unknown queue mode %q", q.Mode)
+	}
+	if q.Ahead < 0 {
+		return fmt.Errorf("queue ahead must not be negative")
+	}
+	return nil
+}
+
+// restingOrder is a limit order on the book with its estimated queue position,
+// or a conditional order waiting for its trigger
+type restingOrder struct {
+	order   *Order
+	ahead   float64 // Quantity still ahead of the order
+	traded  float64 // Quantity traded at the price after the queue ahead cleared
+	extreme float64 // Best price seen by a trailing stop
+}
+
+// newRestingOrder places an order at the back of the queue
+func (q QueueModel) newRestingOrder(order *Order) *restingOrder {
+	return &restingOrder{order: order, ahead: q.Ahead}
+}
+
+// fills reports whether a trade at point fills the resting order
+func (q QueueModel) fills(r *restingOrder, point ChartPoint) bool {
+	order := r.order
+	if !crosses(order, point.Price) {
+		return false
+	}
+	if q.Mode == QueueTouch || point.Price != order.Price {
+		return true
+	}
+
+	// A buy rests on the bid and trades against aggressive sellers, and vice versa
+	if q.Mode == QueueConservative && point.IsBuyerMaker != order.IsBuy {
+		return false
+	}
+
+	volume := point.Qty
+	if r.ahead > 0 {
+		consumed := min(r.ahead, volume)
+		r.ahead -= consumed
+		volume -= consumed
+	}
+	r.traded += volume
+
+	if q.Mode == QueueOptimistic {
+		return r.ahead <= 0
+	}
+	return r.traded >= order.Qty
+}
@@ -0,0 +1,191 @@
+package backtester
+
+import (
+	"fmt"
+	"hft-backtester/indicators"
+	"math"
+)
+
+// SlippageModel sets the price at which orders taking liquidity fill
+type SlippageModel interface {
+	// Update is called with every trade before orders are matched against it
+	Update(point ChartPoint)
+	// Price returns the fill price for qty of order given the last trade price of its symbol
+	Price(order *Order, qty, price float64) float64
+}
+
+// SlippageConfig selects a built-in slippage model and its parameters
+type SlippageConfig struct {
+	Model  string  `json:"model,omitempty"`  // Empty for none, fixed, spread, volatility or participation
+	Bps    float64 `json:"bps,omitempty"`    // fixed: slippage; spread: assumed spread, estimated from trades when zero
+	Factor float64 `json:"factor,omitempty"` // volatility: multiple of the return std dev; participation: bps per 100% of window volume
+	Window int     `json:"window,omitempty"` // Trades used to estimate spread, volatility or volume (default 100)
+}
+
+// NewSlippageModel creates a built-in slippage model, or nil if none is selected
+func NewSlippageModel(config SlippageConfig) (SlippageModel, error) {
+	if config.Bps < 0 || config.Factor < 0 {
+		return nil, fmt.Errorf("slippage parameters must not be negative")
+	}
+	window := config.Window
+	if window <= 0 {
+		window = 100
+	}
+
+	switch config.Model {
+	case "":
+		return nil, nil
+	case "fixed":
+		return &FixedSlippage{Bps: config.Bps}, nil
+	case "spread":
+		return NewSpreadSlippage(config.Bps, window), nil
+	case "volatility":
+		return NewVolatilitySlippage(config.Factor, window), nil
+	case "participation":
+		return NewParticipationSlippage(config.Factor, window), nil
+	}
+	return nil, fmt.Errorf("unknown slippage model %q", config.Model)
+}
+
+// slip moves price against the order side by fraction
+func slip(order *Order, price, fraction float64) float64 {
+	if order.IsBuy {
+		return price * (1 + fraction)
+	}
+	return price * (1 - fraction)
+}
+
+// FixedSlippage fills every taking order a fixed number of basis points worse than the last trade
+type FixedSlippage struct {
+	Bps float64
+}
+
+// Update implements SlippageModel
+func (s *FixedSlippage) Update(point ChartPoint) {}
+
+// Price implements SlippageModel
+func (s *FixedSlippage) Price(order *Order, qty, price float64) float64 {
+	return slip(order, price, s.Bps/1e4)
+}
+
+// SpreadSlippage charges half the bid-ask spread. Without quotes the spread is estimated
+// as the average gap between the last buyer- and seller-initiated trade prices.
+type SpreadSlippage struct {
+	bps     float64
+	window  int
+	symbols map[string]*spreadEstimate
+}
+
+// spreadEstimate tracks the spread of one symbol
+type spreadEstimate struct {
+	lastBuy, lastSell float64
+	spread            *indicators.SMA // Relative spread samples
+}
+
+// NewSpreadSlippage creates a spread model with a fixed spread in bps, or an estimated one if bps is zero
+func NewSpreadSlippage(bps float64, window int) *SpreadSlippage {
+	return &SpreadSlippage{bps: bps, window: window, symbols: make(map[string]*spreadEstimate)}
+}
+
+// Update implements SlippageModel
+func (s *SpreadSlippage) Update(point ChartPoint) {
+	if s.bps > 0 {
+		return
+	}
+	estimate, exists := s.symbols[point.Symbol]
+	if !exists {
+		estimate = &spreadEstimate{spread: indicators.NewSMA(s.window)}
+		s.symbols[point.Symbol] = estimate
+	}
+
+	if point.IsBuyerMaker {
+		estimate.lastSell = point.Price // Seller hit the bid
+	} else {
+		estimate.lastBuy = point.Price // Buyer lifted the ask
+	}
+	if estimate.lastBuy > 0 && estimate.lastSell > 0 {
+		mid := (estimate.lastBuy + estimate.lastSell) / 2
+		estimate.spread.Update(math.Max(estimate.lastBuy-estimate.lastSell, 0) / mid)
+	}
+}
+
+// Price implements SlippageModel
+func (s *SpreadSlippage) Price(order *Order, qty, price float64) float64 {
+	spread := s.bps / 1e4
+	if estimate, exists := s.symbols[order.Symbol]; exists && spread == 0 {
+		spread = estimate.spread.Value()
+	}
+	return slip(order, price, spread/2)
+}
+
+// VolatilitySlippage slips by a multiple of the standard deviation of recent trade-to-trade returns
+type VolatilitySlippage struct {
+	factor  float64
+	window  int
+	symbols map[string]*volatilityEstimate
+}
+
+// volatilityEstimate tracks the return volatility of one symbol
+type volatilityEstimate struct {
+	lastPrice float64
+	returns   *indicators.RollingVariance
+}
+
+// NewVolatilitySlippage creates a volatility-proportional model
+func NewVolatilitySlippage(factor float64, window int) *VolatilitySlippage {
+	return &VolatilitySlippage{factor: factor, window: window, symbols: make(map[string]*volatilityEstimate)}
+}
+
+// Update implements SlippageModel
+func (s *VolatilitySlippage) Update(point ChartPoint) {
+	estimate, exists := s.symbols[point.Symbol]
+	if !exists {
+		estimate = &volatilityEstimate{returns: indicators.NewRollingVariance(s.window)}
+		s.symbols[point.Symbol] = estimate
+	}
+	if estimate.lastPrice > 0 && point.Price > 0 {
+		estimate.returns.Update(math.Log(point.Price / estimate.lastPrice))
+	}
+	estimate.lastPrice = point.Price
+}
+
+// Price implements SlippageModel
+func (s *VolatilitySlippage) Price(order *Order, qty, price float64) float64 {
+	estimate, exists := s.symbols[order.Symbol]
+	if !exists {
+		return price
+	}
+	return slip(order, price, s.factor*estimate.returns.StdDev())
+}
+
+// ParticipationSlippage slips in proportion to the order size relative to recent traded volume
+type ParticipationSlippage struct {
+	factor  float64
+	window  int
+	volumes map[string]*indicators.Sum
+}
+
+// NewParticipationSlippage creates a volume-participation model charging factor bps
+// for an order as large as the volume of the last window trades
+func NewParticipationSlippage(factor float64, window int) *ParticipationSlippage {
+	return &ParticipationSlippage{factor: factor, window: window, volumes: make(map[string]*indicators.Sum)}
+}
+
+// Update implements SlippageModel
+func (s *ParticipationSlippage) Update(point ChartPoint) {
+	volume, exists := s.volumes[point.Symbol]
+	if !exists {
+		volume = indicators.NewSum(s.window)
+		s.volumes[point.Symbol] = volume
+	}
+	volume.Update(point.Qty)
+}
+
+// Price implements SlippageModel
+func (s *ParticipationSlippage) Price(order *Order, qty, price float64) float64 {
+	volume, exists := s.volumes[order.Symbol]
+	if !exists || volume.Value() <= 0 {
+		return price
+	}
+	return slip(order, price, s.factor/1e4*qty/volume.Value())
+}
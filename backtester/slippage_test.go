@@ -0,0 +1,76 @@
+package backtester
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSlippageModels(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   SlippageConfig
+		points   []ChartPoint // Trades before pricing
+		qty      float64
+		wantBuy  float64 // Fill prices against a last trade of 100
+		wantSell float64
+	}{
+		{"fixed", SlippageConfig{Model: "fixed", Bps: 10}, nil, 1, 100.1, 99.9},
+		{"fixed spread charges half", SlippageConfig{Model: "spread", Bps: 20}, nil, 1, 100.1, 99.9},
+		{"spread without both sides", SlippageConfig{Model: "spread"}, []ChartPoint{{Price: 100.1}}, 1, 100, 100},
+		{"estimated spread", SlippageConfig{Model: "spread"}, []ChartPoint{{Price: 100.1}, {Price: 99.9, IsBuyerMaker: true}}, 1, 100.1, 99.9},
+		{"volatility without returns", SlippageConfig{Model: "volatility", Factor: 2}, []ChartPoint{{Price: 100}}, 1, 100, 100},
+		{"volatility", SlippageConfig{Model: "volatility", Factor: 2}, []ChartPoint{{Price: 100}, {Price: 100 * math.Exp(0.01)}, {Price: 100}}, 1, 102, 98},
+		{"participation without volume", SlippageConfig{Model: "participation", Factor: 10}, nil, 3, 100, 100},
+		{"participation", SlippageConfig{Model: "participation", Factor: 10, Window: 3}, []ChartPoint{{Qty: 1}, {Qty: 2}, {Qty: 3}}, 3, 100.05, 99.95},
+		{"participation over the window only", SlippageConfig{Model: "participation", Factor: 10, Window: 2}, []ChartPoint{{Qty: 100}, {Qty: 2}, {Qty: 4}}, 3, 100.05, 99.95},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, err := NewSlippageModel(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, point := range tt.points {
+				point.Symbol = testSymbol
+				model.Update(point)
+			}
+
+			buy := model.Price(&Order{Symbol: testSymbol, IsBuy: true}, tt.qty, 100)
+			sell := model.Price(&Order{Symbol: testSymbol}, tt.qty, 100)
+			if !near(buy, tt.wantBuy) || !near(sell, tt.wantSell) {
+				t.Fatalf("buy at %v and sell at %v, want %v and %v", buy, sell, tt.wantBuy, tt.wantSell)
+			}
+		})
+	}
+}
+
+func TestSlippageConfig(t *testing.T) {
+	if model, err := NewSlippageModel(SlippageConfig{}); model != nil || err != nil {
+		t.Fatalf("want no model by default, got %v, %v", model, err)
+	}
+	for _, config := range []SlippageConfig{{Model: "random"}, {Model: "fixed", Bps: -1}, {Model: "volatility", Factor: -1}} {
+		if _, err := NewSlippageModel(config); err == nil {
+			t.Fatalf("%+v was accepted", config)
+		}
+	}
+}
+
+func TestExchangeSlipsTakingOrders(t *testing.T) {
+	ex := newTestExchange(QueueModel{})
+	ex.SetSlippageModel(&FixedSlippage{Bps: 10})
+	ex.Match(trade(0, 100, 1))
+
+	if filled := trades(ex.Submit(&Order{Symbol: testSymbol, Qty: 1, IsBuy: true})); len(filled) != 1 || !near(filled[0].Price, 100.1) {
+		t.Fatalf("want the market buy filled at 100.1, got %+v", filled)
+	}
+	if filled := trades(ex.Submit(&Order{Symbol: testSymbol, Type: LimitOrder, Price: 99, Qty: 1})); len(filled) != 1 || !near(filled[0].Price, 99.9) {
+		t.Fatalf("want the marketable sell filled at 99.9, got %+v", filled)
+	}
+
+	// Resting orders provide liquidity and fill at their limit
+	ex.Submit(&Order{Symbol: testSymbol, Type: LimitOrder, Price: 99, Qty: 1, IsBuy: true})
+	if filled := trades(ex.Match(trade(1, 98, 1))); len(filled) != 1 || filled[0].Price != 99 {
+		t.Fatalf("want the resting buy filled at 99, got %+v", filled)
+	}
+}
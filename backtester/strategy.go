@@ -0,0 +1,86 @@
+package backtester
+
+import "time"
+
+// Signal represents a trading signal
+type Signal struct {
+	Action string
+	Price  float64
+	Time   time.Time
+
+	// Target is the desired position as a fraction of PositionSize for Action "TARGET",
+	// from -1 (full short) to 1 (full long)
+	Target float64
+
+	// CancelAll cancels the strategy's resting orders before any new orders are placed
+	CancelAll bool
+	// Cancel lists IDs of resting orders to cancel, after CancelAll
+	Cancel []string
+	// Replace amends resting orders identified by Order.ID to the non-zero Price and Qty
+	// given. Replacements are applied after cancels and before new orders.
+	Replace []*Order
+	// Orders are submitted as-is in addition to the order implied by Action;
+	// empty Symbol and zero Time default to the current tick
+	Orders []*Order
+
+	// Reason and Values explain the signal; they are copied to the orders
+	// and trades it produces and recorded in the decision log
+	Reason string
+	Values map[string]float64
+}
+
+// idle reports whether the signal asks the exchange for nothing
+func (s Signal) idle() bool {
+	return (s.Action == "HOLD" || s.Action == "") && !s.CancelAll &&
+		len(s.Cancel) == 0 && len(s.Replace) == 0 && len(s.Orders) == 0
+}
+
+// Strategy produces a trading signal for each market event.
+// Strategies may also implement any of the optional lifecycle
+// interfaces below to be notified by the engine.
+type Strategy interface {
+	OnTick(point ChartPoint) Signal
+}
+
+// Starter is notified once before the first tick with the engine
+// configuration and any warm-up data preceding the backtest window
+type Starter interface {
+	OnStart(config Config, warmup []ChartPoint)
+}
+
+// FillListener is notified when an order from a signal is executed
+type FillListener interface {
+	OnFill(trade *Trade)
+}
+
+// RejectListener is notified when an order from a signal cannot be executed,
+// e.g. with an *InsufficientFundsError
+type RejectListener interface {
+	OnReject(order *Order, err error)
+}
+
+// TimerListener is called every TimerInterval of market time,
+// before the tick that crosses the timer boundary
+type TimerListener interface {
+	TimerInterval() time.Duration
+	OnTimer(now time.Time)
+}
+
+// Finisher is notified after the last tick with the final result
+type Finisher interface {
+	OnEnd(result *BacktestResult)
+}
+
+// SeriesPublisher exposes named indicator values after each tick,
+// collected into BacktestResult.Series for charting
+type SeriesPublisher interface {
+	Series() map[string]float64
+}
+
+// BarListener subscribes to time bars aggregated from the trades. OnBar is called
+// for each bar once it closes, before the first tick after it, in order of bar end
+// time with shorter timeframes first. Its signal is handled like one from OnTick.
+type BarListener interface {
+	Timeframes() []time.Duration
+	OnBar(bar Bar) Signal
+}
@@ -0,0 +1,69 @@
+package backtester
+
+import (
+	"errors"
+	"time"
+)
+
+// TimeInForce sets how long an order stays working
+type TimeInForce string
+
+const (
+	GTC TimeInForce = ""    // Good till cancelled
+	IOC TimeInForce = "IOC" // Immediate or cancel: what cannot fill on arrival is cancelled
+	FOK TimeInForce = "FOK" // Fill or kill: rejected unless it fills completely on arrival
+	GTD TimeInForce = "GTD" // Good till date: cancelled at ExpireTime
+)
+
+// Time-in-force and post-only errors reported by the exchange
+var (
+	ErrUnknownTimeInForce = errors.New("unknown time in force")
+	ErrMissingExpireTime  = errors.New("GTD order needs an expire time")
+	ErrPostOnlyWouldCross = errors.New("post-only order would take liquidity")
+	ErrIOCNotFilled       = errors.New("IOC order could not fill on arrival")
+	ErrFOKNotFilled       = errors.New("FOK order could not fill completely on arrival")
+	ErrExpired            = errors.New("GTD order expired")
+)
+
+// validateTimeInForce checks the time in force of a new order at time now
+func validateTimeInForce(order *Order, now time.Time) error {
+	switch order.TimeInForce {
+	case GTC, IOC, FOK:
+	case GTD:
+		if order.ExpireTime == nil {
+			return ErrMissingExpireTime
+		}
+		if order.expired(now) {
+			return ErrExpired
+		}
+	default:
+		return ErrUnknownTimeInForce
+	}
+	return nil
+}
+
+// expired reports whether a GTD order has reached its expire time
+func (o *Order) expired(now time.Time) bool {
+	return o.TimeInForce == GTD && o.ExpireTime != nil && !now.Before(*o.ExpireTime)
+}
+
+// notFilledError returns the error for an order that cannot fill on arrival
+// if its time in force keeps it off the book, or nil if it may rest
+func notFilledError(order *Order) error {
+	switch order.TimeInForce {
+	case IOC:
+		return ErrIOCNotFilled
+	case FOK:
+		return ErrFOKNotFilled
+	}
+	return nil
+}
+
+// rest puts an order that cannot fill on arrival on the book, unless its time in force forbids it
+func (ex *Exchange) rest(resting *restingOrder) []Execution {
+	if err := notFilledError(resting.order); err != nil {
+		return []Execution{{Order: resting.order, Err: err}}
+	}
+	ex.open = append(ex.open, resting)
+	return nil
+}
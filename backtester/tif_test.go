@@ -0,0 +1,72 @@
+package backtester
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeInForce(t *testing.T) {
+	expire := func(ms int64) *time.Time {
+		at := time.UnixMilli(ms)
+		return &at
+	}
+
+	tests := []struct {
+		name     string
+		order    Order
+		wantErr  error
+		wantFill bool
+		wantOpen bool // Resting after submission
+	}{
+		{"unknown time in force", Order{TimeInForce: "DAY"}, ErrUnknownTimeInForce, false, false},
+		{"GTD without an expire time", Order{Type: LimitOrder, Price: 99, IsBuy: true, TimeInForce: GTD}, ErrMissingExpireTime, false, false},
+		{"GTD already expired", Order{Type: LimitOrder, Price: 99, IsBuy: true, TimeInForce: GTD, ExpireTime: expire(0)}, ErrExpired, false, false},
+		{"GTD rests until it expires", Order{Type: LimitOrder, Price: 99, IsBuy: true, TimeInForce: GTD, ExpireTime: expire(10)}, nil, false, true},
+		{"IOC limit away from the market", Order{Type: LimitOrder, Price: 99, IsBuy: true, TimeInForce: IOC}, ErrIOCNotFilled, false, false},
+		{"FOK limit away from the market", Order{Type: LimitOrder, Price: 99, IsBuy: true, TimeInForce: FOK}, ErrFOKNotFilled, false, false},
+		{"FOK marketable limit", Order{Type: LimitOrder, Price: 100, IsBuy: true, TimeInForce: FOK}, nil, true, false},
+		{"post-only order that would cross", Order{Type: LimitOrder, Price: 100, IsBuy: true, PostOnly: true}, ErrPostOnlyWouldCross, false, false},
+		{"post-only order on the book", Order{Type: LimitOrder, Price: 99, IsBuy: true, PostOnly: true}, nil, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ex := newTestExchange(QueueModel{})
+			ex.Match(trade(0, 100, 1))
+			order := tt.order
+			order.Symbol, order.Qty = testSymbol, 1
+
+			executions := ex.Submit(&order)
+			if err := firstErr(executions); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got %v, want %v", err, tt.wantErr)
+			}
+			if filled := len(trades(executions)) > 0; filled != tt.wantFill {
+				t.Fatalf("filled %v, want %v", filled, tt.wantFill)
+			}
+			if open := len(ex.OpenOrders()) > 0; open != tt.wantOpen {
+				t.Fatalf("resting %v, want %v", open, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestGTDExpires(t *testing.T) {
+	ex := newTestExchange(QueueModel{})
+	ex.Match(trade(0, 100, 1))
+	expire := time.UnixMilli(10)
+	order := &Order{Symbol: testSymbol, Type: LimitOrder, Price: 99, Qty: 1, IsBuy: true, TimeInForce: GTD, ExpireTime: &expire}
+	ex.Submit(order)
+
+	if executions := ex.Match(trade(9, 99.5, 1)); len(executions) != 0 {
+		t.Fatalf("order expired early: %v", firstErr(executions))
+	}
+	// Expiry comes before matching, so the trade at the price does not fill it
+	executions := ex.Match(trade(10, 98, 1))
+	if len(executions) != 1 || !errors.Is(executions[0].Err, ErrExpired) {
+		t.Fatalf("want the order expired, got %+v", executions)
+	}
+	if len(ex.OpenOrders()) != 0 {
+		t.Fatalf("expired order is still resting")
+	}
+}
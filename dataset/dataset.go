@@ -0,0 +1,160 @@
+package dataset
+
+import (
+	"encoding/csv"
+	"fmt"
+	"hft-backtester/backtester"
+	"hft-backtester/indicators"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Label kinds
+const (
+	ForwardReturn = "forward" // Log return over the horizon
+	TripleBarrier = "barrier" // +1 take profit, -1 stop loss or 0 horizon, whichever comes first
+)
+
+// Config describes the features and labels of a dataset
+type Config struct {
+	Features   map[string]interface{} // indicators.Set specs
+	Timeframe  time.Duration          // Bar interval, or zero for one row per trade
+	Label      string                 // ForwardReturn (default) or TripleBarrier
+	Horizon    int                    // Rows ahead the label looks at
+	TakeProfit float64                // Upper barrier as a fraction of the price (0.01 = 1%)
+	StopLoss   float64                // Lower barrier as a fraction of the price
+}
+
+// Row is one labeled sample
+type Row struct {
+	Time        int64
+	Price       float64 // Trade price or bar close
+	Volume      float64
+	Features    []float64 // In the order of Dataset.Names
+	Label       float64
+	LabelReturn float64 // Log return until the label was decided
+	high, low   float64
+}
+
+// Dataset is a table of feature rows with labels
+type Dataset struct {
+	Names []string
+	Rows  []Row
+}
+
+// Build replays points, computing features per trade or per bar and attaching labels.
+// Rows before every feature is ready and rows whose horizon runs past the data are dropped.
+func Build(points []backtester.ChartPoint, config Config) (*Dataset, error) {
+	if config.Horizon <= 0 {
+		return nil, fmt.Errorf("horizon must be positive")
+	}
+	if config.Label == "" {
+		config.Label = ForwardReturn
+	}
+	if config.Label != ForwardReturn && config.Label != TripleBarrier {
+		return nil, fmt.Errorf("unknown label %q", config.Label)
+	}
+	if config.Label == TripleBarrier && (config.TakeProfit <= 0 || config.StopLoss <= 0) {
+		return nil, fmt.Errorf("triple-barrier labels need a positive take profit and stop loss")
+	}
+
+	set, err := indicators.NewSet(config.Features)
+	if err != nil {
+		return nil, err
+	}
+	dataset := &Dataset{Names: set.Names()}
+	values := make(map[string]float64)
+
+	// sample adds a row if all features are ready; unready rows still serve as label prices
+	var rows []Row
+	var ready []bool
+	sample := func(row Row) {
+		set.UpdateBar(row.high, row.low, row.Price, row.Volume)
+		isReady := set.Ready()
+		if isReady {
+			set.Values(values)
+			row.Features = make([]float64, len(dataset.Names))
+			for i, name := range dataset.Names {
+				row.Features[i] = values[name]
+			}
+		}
+		rows = append(rows, row)
+		ready = append(ready, isReady)
+	}
+
+	if config.Timeframe > 0 {
+		// Bars still open at the end of the data are incomplete and left out
+		aggregator := backtester.NewBarAggregator(config.Timeframe)
+		for _, point := range points {
+			for _, bar := range aggregator.Close(point.Time) {
+				sample(Row{Time: bar.End, Price: bar.Close, Volume: bar.Volume, high: bar.High, low: bar.Low})
+			}
+			aggregator.Update(point)
+		}
+	} else {
+		for _, point := range points {
+			sample(Row{Time: point.Time, Price: point.Price, Volume: point.Qty, high: point.Price, low: point.Price})
+		}
+	}
+
+	for i := 0; i+config.Horizon < len(rows); i++ {
+		if !ready[i] {
+			continue
+		}
+		row := rows[i]
+		if config.Label == TripleBarrier {
+			row.Label, row.LabelReturn = barrierLabel(rows[i:i+config.Horizon+1], config.TakeProfit, config.StopLoss)
+		} else {
+			row.LabelReturn = math.Log(rows[i+config.Horizon].Price / row.Price)
+			row.Label = row.LabelReturn
+		}
+		dataset.Rows = append(dataset.Rows, row)
+	}
+	return dataset, nil
+}
+
+// barrierLabel labels the first row by the barrier the following rows touch first.
+// When a bar touches both barriers the stop loss is assumed to come first.
+func barrierLabel(rows []Row, takeProfit, stopLoss float64) (float64, float64) {
+	entry := rows[0].Price
+	upper, lower := entry*(1+takeProfit), entry*(1-stopLoss)
+	for _, row := range rows[1:] {
+		if row.low <= lower {
+			return -1, math.Log(lower / entry)
+		}
+		if row.high >= upper {
+			return 1, math.Log(upper / entry)
+		}
+	}
+	return 0, math.Log(rows[len(rows)-1].Price / entry)
+}
+
+// WriteCSV writes the dataset with a header row
+func (d *Dataset) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := append([]string{"time", "price", "volume"}, d.Names...)
+	if err := writer.Write(append(header, "label", "label_return")); err != nil {
+		return err
+	}
+
+	record := make([]string, 0, len(header)+2)
+	for _, row := range d.Rows {
+		record = append(record[:0], strconv.FormatInt(row.Time, 10), formatFloat(row.Price), formatFloat(row.Volume))
+		for _, value := range row.Features {
+			record = append(record, formatFloat(value))
+		}
+		record = append(record, formatFloat(row.Label), formatFloat(row.LabelReturn))
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// formatFloat formats a value with the shortest exact representation
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"hft-backtester/dataset"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// FeatureRequest represents the parameters for a feature and label export
+type FeatureRequest struct {
+	Symbol     string                 `json:"symbol"`
+	Hour       string                 `json:"hour"`
+	Features   map[string]interface{} `json:"features"`  // Indicator specs keyed by column name
+	Timeframe  string                 `json:"timeframe"` // Bar interval such as "1m"; empty for one row per trade
+	Label      string                 `json:"label"`     // forward (default) or barrier
+	Horizon    int                    `json:"horizon"`   // Rows ahead the label looks at
+	TakeProfit float64                `json:"take_profit"`
+	StopLoss   float64                `json:"stop_loss"`
+	Format     string                 `json:"format"` // Only csv; Parquet is not implemented
+}
+
+// ExportFeaturesHandler replays trades and returns indicator features with labels as CSV
+func ExportFeaturesHandler(c *fiber.Ctx) error {
+	var req FeatureRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request format"})
+	}
+	if req.Format != "" && req.Format != "csv" {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("unsupported format %q, only csv is available", req.Format)})
+	}
+
+	config := dataset.Config{
+		Features:   req.Features,
+		Label:      req.Label,
+		Horizon:    req.Horizon,
+		TakeProfit: req.TakeProfit,
+		StopLoss:   req.StopLoss,
+	}
+	if config.Horizon <= 0 {
+		config.Horizon = 100 // Default value
+	}
+	if req.Timeframe != "" {
+		timeframe, err := time.ParseDuration(req.Timeframe)
+		if err != nil || timeframe < time.Millisecond {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("invalid timeframe %q", req.Timeframe)})
+		}
+		config.Timeframe = timeframe
+	}
+
+	symbol := req.Symbol
+	if symbol == "" {
+		symbol = DefaultSymbol
+	}
+	var points []ChartPoint
+	var err error
+	if req.Hour != "" {
+		points, err = LoadSymbolTradesByHour(symbol, req.Hour, 0)
+	} else {
+		points, err = LoadSymbolTrades(symbol, 0)
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	data, err := dataset.Build(toBacktesterPoints(points), config)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var buf bytes.Buffer
+	if err := data.WriteCSV(&buf); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-features.csv"`, symbol))
+	return c.Type("csv").Send(buf.Bytes())
+}
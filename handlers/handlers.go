@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/csv"
+	"fmt"
 	"hft-backtester/backtester"
 	"hft-backtester/strategies"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"time"
 
@@ -21,9 +25,30 @@ type Trade struct {
 	IsBuyerMaker string `json:"is_buyer_maker"`
 }
 
+// DefaultSymbol is the symbol loaded when a request does not name any
+const DefaultSymbol = "STBLUSDT"
+
 type ChartPoint struct {
-	Time  int64   `json:"time"`
-	Price float64 `json:"price"`
+	Symbol       string  `json:"symbol,omitempty"`
+	Time         int64   `json:"time"`
+	Price        float64 `json:"price"`
+	Qty          float64 `json:"qty,omitempty"`
+	IsBuyerMaker bool    `json:"is_buyer_maker,omitempty"`
+}
+
+// parsePoint converts a trade record [id, price, qty, quote_qty, time, is_buyer_maker] into a ChartPoint
+func parsePoint(record []string, timestamp int64) ChartPoint {
+	price, _ := strconv.ParseFloat(record[1], 64)
+	qty, _ := strconv.ParseFloat(record[2], 64)
+	point := ChartPoint{
+		Time:  timestamp,
+		Price: price,
+		Qty:   qty,
+	}
+	if len(record) >= 6 {
+		point.IsBuyerMaker, _ = strconv.ParseBool(record[5])
+	}
+	return point
 }
 
 type HourInfo struct {
@@ -33,14 +58,25 @@ type HourInfo struct {
 
 // BacktestRequest represents the parameters for a backtest
 type BacktestRequest struct {
-	Strategy       string                 `json:"strategy"`
-	InitialCash    float64                `json:"initial_cash"`
-	PositionSize   float64                `json:"position_size"`
-	Commission     float64                `json:"commission"`
-	Hour           string                 `json:"hour"`
-	StartTime      string                 `json:"start_time"`
-	EndTime        string                 `json:"end_time"`
-	StrategyParams map[string]interface{} `json:"strategy_params"`
+	Strategy       string                    `json:"strategy"`
+	InitialCash    float64                   `json:"initial_cash"`
+	PositionSize   float64                   `json:"position_size"`
+	Commission     float64                   `json:"commission"`
+	Hour           string                    `json:"hour"`
+	Symbols        []string                  `json:"symbols"`     // Replayed together in time order, first is primary
+	Warmup         int                       `json:"warmup"`      // Trades preceding the window fed to the strategy without trading
+	QueueModel     string                    `json:"queue_model"` // Empty to fill on touch, conservative or optimistic
+	QueueAhead     float64                   `json:"queue_ahead"` // Quantity assumed ahead of resting orders
+	Slippage       backtester.SlippageConfig `json:"slippage"`
+	Impact         backtester.ImpactConfig   `json:"impact"`
+	Fees           backtester.FeeConfig      `json:"fees"`          // Maker/taker tiers with rates and discount in percent; replaces commission when set
+	ExchangeInfo   string                    `json:"exchange_info"` // exchangeInfo file in ExchangeInfoDir with the symbol filters
+	FilterMode     string                    `json:"filter_mode"`   // Empty to round orders to the tick and step, or reject
+	Latency        backtester.LatencyConfig  `json:"latency"`
+	Participation  float64                   `json:"participation"` // Percent of each trade's quantity our orders may fill, 0 for no cap
+	StartTime      string                    `json:"start_time"`
+	EndTime        string                    `json:"end_time"`
+	StrategyParams map[string]interface{}    `json:"strategy_params"`
 }
 
 func LoadTradesByHour(hour string) ([]ChartPoint, error) {
@@ -48,7 +84,33 @@ func LoadTradesByHour(hour string) ([]ChartPoint, error) {
 }
 
 func LoadTradesByHourWithLimit(hour string, limit int) ([]ChartPoint, error) {
-	file, err := os.Open("upload/trades/STBLUSDT-trades-2025-09-20.csv")
+	return LoadSymbolTradesByHour(DefaultSymbol, hour, limit)
+}
+
+// ExchangeInfoDir is where exchangeInfo files with symbol filters must live
+var ExchangeInfoDir = "upload/exchangeinfo"
+
+// loadExchangeInfo reads the symbol filters from a file in ExchangeInfoDir
+func loadExchangeInfo(name string) (map[string]backtester.SymbolFilters, error) {
+	if filepath.Base(name) != name || name == "." || name == ".." {
+		return nil, fmt.Errorf("exchange info must be a file name in %s", ExchangeInfoDir)
+	}
+	return backtester.LoadExchangeInfo(filepath.Join(ExchangeInfoDir, name))
+}
+
+// openTrades opens the trades file of a symbol
+func openTrades(symbol string) (*os.File, error) {
+	for _, r := range symbol {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return nil, fmt.Errorf("invalid symbol %q", symbol)
+		}
+	}
+	return os.Open("upload/trades/" + symbol + "-trades-2025-09-20.csv")
+}
+
+// LoadSymbolTradesByHour loads up to limit trades of a symbol within an hour, sampling evenly beyond the limit
+func LoadSymbolTradesByHour(symbol, hour string, limit int) ([]ChartPoint, error) {
+	file, err := openTrades(symbol)
 	if err != nil {
 		return nil, err
 	}
@@ -101,11 +163,7 @@ func LoadTradesByHourWithLimit(hour string, limit int) ([]ChartPoint, error) {
 					}
 				}
 
-				price, _ := strconv.ParseFloat(record[1], 64)
-				points = append(points, ChartPoint{
-					Time:  timestamp,
-					Price: price,
-				})
+				points = append(points, parsePoint(record, timestamp))
 			}
 		}
 	}
@@ -113,8 +171,53 @@ func LoadTradesByHourWithLimit(hour string, limit int) ([]ChartPoint, error) {
 	return points, nil
 }
 
+// LoadSymbolTradesBefore loads the last n trades of a symbol before the given time
+func LoadSymbolTradesBefore(symbol string, before int64, n int) ([]ChartPoint, error) {
+	file, err := openTrades(symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+
+	// Read and skip header
+	_, err = reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the most recent n points in a ring buffer, grown as trades are read
+	ring := make([]ChartPoint, 0, min(n, 1024))
+	next := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(record) >= 5 {
+			timestamp, _ := strconv.ParseInt(record[4], 10, 64)
+			if timestamp >= before {
+				break
+			}
+			if len(ring) < n {
+				ring = append(ring, parsePoint(record, timestamp))
+			} else {
+				ring[next] = parsePoint(record, timestamp)
+				next = (next + 1) % n
+			}
+		}
+	}
+
+	return append(ring[next:], ring[:next]...), nil
+}
+
 func GetAvailableHours() ([]HourInfo, error) {
-	file, err := os.Open("upload/trades/STBLUSDT-trades-2025-09-20.csv")
+	file, err := openTrades(DefaultSymbol)
 	if err != nil {
 		return nil, err
 	}
@@ -158,7 +261,12 @@ func GetAvailableHours() ([]HourInfo, error) {
 }
 
 func LoadTrades(limit int) ([]ChartPoint, error) {
-	file, err := os.Open("upload/trades/STBLUSDT-trades-2025-09-20.csv")
+	return LoadSymbolTrades(DefaultSymbol, limit)
+}
+
+// LoadSymbolTrades loads the first limit trades of a symbol (all when limit is 0)
+func LoadSymbolTrades(symbol string, limit int) ([]ChartPoint, error) {
+	file, err := openTrades(symbol)
 	if err != nil {
 		return nil, err
 	}
@@ -186,13 +294,8 @@ func LoadTrades(limit int) ([]ChartPoint, error) {
 
 		// record format: [id, price, qty, quote_qty, time, is_buyer_maker]
 		if len(record) >= 5 {
-			price, _ := strconv.ParseFloat(record[1], 64)
-			time, _ := strconv.ParseInt(record[4], 10, 64)
-
-			points = append(points, ChartPoint{
-				Time:  time,
-				Price: price,
-			})
+			timestamp, _ := strconv.ParseInt(record[4], 10, 64)
+			points = append(points, parsePoint(record, timestamp))
 		}
 
 		count++
@@ -235,21 +338,101 @@ func RunBacktestHandler(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request format"})
 	}
 
+	result, status, err := runBacktest(req, nil)
+	if err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(result)
+}
+
+// DecisionLogHandler runs a backtest and returns every non-HOLD signal as newline-delimited JSON
+func DecisionLogHandler(c *fiber.Ctx) error {
+	var req BacktestRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request format"})
+	}
+
+	var decisions bytes.Buffer
+	if _, status, err := runBacktest(req, &decisions); err != nil {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="decisions.ndjson"`)
+	return c.Send(decisions.Bytes())
+}
+
+// strategyErr returns the error a strategy or any child of a composite reported during the run,
+// with the HTTP status to respond with: 502 for a failed external strategy process, 400 for a
+// strategy that could not work with the request
+func strategyErr(strategy backtester.Strategy) (int, error) {
+	if external, ok := strategy.(*strategies.ExternalStrategy); ok && external.Err() != nil {
+		return 502, external.Err()
+	}
+	if failed, ok := strategy.(interface{ Err() error }); ok && failed.Err() != nil {
+		return 400, failed.Err()
+	}
+	if composite, ok := strategy.(*strategies.CompositeStrategy); ok {
+		for _, child := range composite.Children() {
+			if status, err := strategyErr(child); err != nil {
+				return status, err
+			}
+		}
+	}
+	return 0, nil
+}
+
+// MaxWarmup is the largest number of warm-up trades a request may ask for per symbol
+const MaxWarmup = 1000000
+
+// runBacktest loads the requested data and runs the backtest, writing the decision log
+// to decisionLog if it is not nil. On failure it returns the HTTP status to respond with.
+func runBacktest(req BacktestRequest, decisionLog io.Writer) (*backtester.BacktestResult, int, error) {
 	// Load data for backtesting
-	var trades []ChartPoint
-	var err error
-	if req.Hour != "" {
-		trades, err = LoadTradesByHour(req.Hour)
-		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	symbols := req.Symbols
+	if len(symbols) == 0 {
+		symbols = []string{DefaultSymbol}
+	}
+	if req.Warmup < 0 || req.Warmup > MaxWarmup {
+		return nil, 400, fmt.Errorf("warmup must be between 0 and %d trades", MaxWarmup)
+	}
+	var trades, warmup []ChartPoint
+	for _, symbol := range symbols {
+		var points []ChartPoint
+		var err error
+		if req.Hour != "" {
+			points, err = LoadSymbolTradesByHour(symbol, req.Hour, 10000)
+		} else {
+			// Load default 1000 points if no hour specified
+			points, err = LoadSymbolTrades(symbol, 1000)
 		}
-	} else {
-		// Load default 1000 points if no hour specified
-		trades, err = LoadTrades(1000)
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+			return nil, 500, err
+		}
+
+		var preceding []ChartPoint
+		if req.Warmup > 0 && len(points) > 0 {
+			preceding, err = LoadSymbolTradesBefore(symbol, points[0].Time, req.Warmup)
+			if err != nil {
+				return nil, 500, err
+			}
 		}
+
+		if len(symbols) > 1 {
+			for i := range points {
+				points[i].Symbol = symbol
+			}
+			for i := range preceding {
+				preceding[i].Symbol = symbol
+			}
+		}
+		trades = append(trades, points...)
+		warmup = append(warmup, preceding...)
+	}
+	if len(symbols) > 1 {
+		sort.SliceStable(trades, func(i, j int) bool { return trades[i].Time < trades[j].Time })
+		sort.SliceStable(warmup, func(i, j int) bool { return warmup[i].Time < warmup[j].Time })
 	}
+
 	// Create backtest engine
 	initialCash := req.InitialCash
 	if initialCash <= 0 {
@@ -266,39 +449,104 @@ func RunBacktestHandler(c *fiber.Ctx) error {
 		positionSize = 50.0 // Default value ($50)
 	}
 
-	engine := backtester.NewBacktestEngine(initialCash, commission/100.0, positionSize) // Convert percentage to decimal
-
-	// Convert ChartPoint to backtester.ChartPoint
-	backtesterTrades := make([]backtester.ChartPoint, len(trades))
-	for i, trade := range trades {
-		backtesterTrades[i] = backtester.ChartPoint{
-			Time:  trade.Time,
-			Price: trade.Price,
+	config := backtester.Config{
+		Symbol:         symbols[0],
+		InitialCash:    initialCash,
+		CommissionRate: commission / 100.0, // Convert percentage to decimal
+		PositionSize:   positionSize,
+		Queue:          backtester.QueueModel{Mode: req.QueueModel, Ahead: req.QueueAhead},
+		Latency:        req.Latency,
+		Participation:  req.Participation / 100.0, // Convert percentage to fraction
+	}
+	if err := config.Queue.Validate(); err != nil {
+		return nil, 400, err
+	}
+	if err := config.Latency.Validate(); err != nil {
+		return nil, 400, err
+	}
+	if req.Participation < 0 || req.Participation > 100 {
+		return nil, 400, fmt.Errorf("participation must be between 0 and 100 percent")
+	}
+	if len(symbols) > 1 {
+		config.Symbols = symbols
+	}
+	slippage, err := backtester.NewSlippageModel(req.Slippage)
+	if err != nil {
+		return nil, 400, err
+	}
+	impact, err := backtester.NewImpactModel(req.Impact)
+	if err != nil {
+		return nil, 400, err
+	}
+	for i := range req.Fees.Tiers {
+		req.Fees.Tiers[i].Maker /= 100.0 // Convert percentage to decimal
+		req.Fees.Tiers[i].Taker /= 100.0
+	}
+	if req.Fees.Discount < 0 || req.Fees.Discount > 100 {
+		return nil, 400, fmt.Errorf("fee discount must be between 0 and 100 percent")
+	}
+	req.Fees.Discount /= 100.0
+	fees, err := backtester.NewFeeSchedule(req.Fees)
+	if err != nil {
+		return nil, 400, err
+	}
+	engine := backtester.NewBacktestEngine(config)
+	engine.SetSlippageModel(slippage)
+	engine.SetImpactModel(impact)
+	engine.SetFeeSchedule(fees)
+	if req.FilterMode != "" && req.FilterMode != "reject" {
+		return nil, 400, fmt.Errorf("unknown filter mode %q", req.FilterMode)
+	}
+	if req.ExchangeInfo != "" {
+		filters, err := loadExchangeInfo(req.ExchangeInfo)
+		if err != nil {
+			return nil, 400, err
 		}
+		engine.SetSymbolFilters(filters, req.FilterMode == "reject")
+	}
+	if decisionLog != nil {
+		engine.SetDecisionLog(decisionLog)
 	}
 
-	// Create strategy if specified
-	var strategy interface{}
-	if req.Strategy == "bollinger" {
-		period := 100
-		stdDev := 1.0
+	backtesterTrades := toBacktesterPoints(trades)
 
-		if p, ok := req.StrategyParams["period"].(float64); ok {
-			period = int(p)
+	// Create strategy if specified
+	var strategy backtester.Strategy
+	if req.Strategy != "" {
+		var err error
+		strategy, err = strategies.New(req.Strategy, req.StrategyParams)
+		if err != nil {
+			return nil, 400, err
 		}
-		if s, ok := req.StrategyParams["stdDev"].(float64); ok {
-			stdDev = s
+		if err := strategies.CheckSymbols(strategy, symbols); err != nil {
+			return nil, 400, err
 		}
-
-		strategy = strategies.NewBollingerBandsStrategy(period, stdDev)
 	}
 
-	result := engine.Run(backtesterTrades, strategy)
+	result := engine.RunWithWarmup(toBacktesterPoints(warmup), backtesterTrades, strategy)
+	if status, err := strategyErr(strategy); err != nil {
+		return nil, status, err
+	}
 
 	// Add price data for visualization
 	result.PriceData = backtesterTrades
 
-	return c.JSON(result)
+	return result, 200, nil
+}
+
+// toBacktesterPoints converts ChartPoint to backtester.ChartPoint
+func toBacktesterPoints(points []ChartPoint) []backtester.ChartPoint {
+	converted := make([]backtester.ChartPoint, len(points))
+	for i, point := range points {
+		converted[i] = backtester.ChartPoint{
+			Symbol:       point.Symbol,
+			Time:         point.Time,
+			Price:        point.Price,
+			Qty:          point.Qty,
+			IsBuyerMaker: point.IsBuyerMaker,
+		}
+	}
+	return converted
 }
 
 // HealthHandler handles health check requests
@@ -0,0 +1,152 @@
+package indicators
+
+// Sum is a rolling sum over a fixed window
+type Sum struct {
+	window *ring
+	value  float64
+}
+
+// NewSum creates a rolling sum over period values
+func NewSum(period int) *Sum {
+	return &Sum{window: newRing(period)}
+}
+
+// Update adds a new value to the sum
+func (s *Sum) Update(v float64) {
+	s.value += v
+	if old, evicted := s.window.push(v); evicted {
+		s.value -= old
+	}
+}
+
+// Value returns the current sum
+func (s *Sum) Value() float64 {
+	return s.value
+}
+
+// Ready reports whether the window is full
+func (s *Sum) Ready() bool {
+	return s.window.full()
+}
+
+// SMA is a simple moving average over a fixed window
+type SMA struct {
+	sum *Sum
+}
+
+// NewSMA creates a simple moving average over period values
+func NewSMA(period int) *SMA {
+	return &SMA{sum: NewSum(period)}
+}
+
+// Update adds a new value to the average
+func (s *SMA) Update(v float64) {
+	s.sum.Update(v)
+}
+
+// Value returns the current average
+func (s *SMA) Value() float64 {
+	if s.sum.window.size == 0 {
+		return 0
+	}
+	return s.sum.Value() / float64(s.sum.window.size)
+}
+
+// Ready reports whether the window is full
+func (s *SMA) Ready() bool {
+	return s.sum.Ready()
+}
+
+// EMA is an exponential moving average seeded with the first value
+type EMA struct {
+	alpha  float64
+	period int
+	count  int
+	value  float64
+}
+
+// NewEMA creates an exponential moving average with alpha = 2/(period+1)
+func NewEMA(period int) *EMA {
+	if period < 1 {
+		period = 1
+	}
+	return &EMA{
+		alpha:  2.0 / float64(period+1),
+		period: period,
+	}
+}
+
+// Update adds a new value to the average
+func (e *EMA) Update(v float64) {
+	if e.count == 0 {
+		e.value = v
+	} else {
+		e.value += e.alpha * (v - e.value)
+	}
+	if e.count < e.period {
+		e.count++
+	}
+}
+
+// Value returns the current average
+func (e *EMA) Value() float64 {
+	return e.value
+}
+
+// Ready reports whether at least period values have been seen
+func (e *EMA) Ready() bool {
+	return e.count >= e.period
+}
+
+// VWAP is a volume-weighted average price over a window of trades.
+// A period of zero or less accumulates over the whole stream.
+type VWAP struct {
+	notional *ring
+	volume   *ring
+	sumPV    float64
+	sumV     float64
+	rolling  bool
+	count    int
+}
+
+// NewVWAP creates a volume-weighted average price over period trades
+func NewVWAP(period int) *VWAP {
+	v := &VWAP{rolling: period > 0}
+	if v.rolling {
+		v.notional = newRing(period)
+		v.volume = newRing(period)
+	}
+	return v
+}
+
+// Update adds a trade to the average
+func (v *VWAP) Update(price, qty float64) {
+	v.sumPV += price * qty
+	v.sumV += qty
+	v.count++
+	if !v.rolling {
+		return
+	}
+	if old, evicted := v.notional.push(price * qty); evicted {
+		v.sumPV -= old
+	}
+	if old, evicted := v.volume.push(qty); evicted {
+		v.sumV -= old
+	}
+}
+
+// Value returns the current volume-weighted price
+func (v *VWAP) Value() float64 {
+	if v.sumV <= 0 {
+		return 0
+	}
+	return v.sumPV / v.sumV
+}
+
+// Ready reports whether the window is full (or any trade was seen when cumulative)
+func (v *VWAP) Ready() bool {
+	if !v.rolling {
+		return v.count > 0
+	}
+	return v.volume.full()
+}
@@ -0,0 +1,120 @@
+package indicators
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// randomWalk returns n deterministic prices wandering around start
+func randomWalk(n int, start float64) []float64 {
+	rng := rand.New(rand.NewSource(1))
+	prices := make([]float64, n)
+	price := start
+	for i := range prices {
+		price *= math.Exp(0.01 * rng.NormFloat64())
+		prices[i] = price
+	}
+	return prices
+}
+
+// approx reports whether got matches want up to a relative tolerance
+func approx(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-8*math.Max(1, math.Abs(want))
+}
+
+func TestSMAMatchesBruteForce(t *testing.T) {
+	prices := randomWalk(100000, 10000)
+	for _, period := range []int{1, 2, 20, 500} {
+		sma := NewSMA(period)
+		for i, price := range prices {
+			sma.Update(price)
+			start := max(0, i+1-period)
+			sum := 0.0
+			for _, p := range prices[start : i+1] {
+				sum += p
+			}
+			if want := sum / float64(i+1-start); !approx(sma.Value(), want) {
+				t.Fatalf("period %d at %d: got %v, want %v", period, i, sma.Value(), want)
+			}
+			if sma.Ready() != (i+1 >= period) {
+				t.Fatalf("period %d at %d: ready %v", period, i, sma.Ready())
+			}
+		}
+	}
+}
+
+func TestEMAMatchesBruteForce(t *testing.T) {
+	prices := randomWalk(1000, 100)
+	ema := NewEMA(10)
+	want := prices[0]
+	for i, price := range prices {
+		ema.Update(price)
+		if i > 0 {
+			want = 2.0/11*price + 9.0/11*want
+		}
+		if !approx(ema.Value(), want) {
+			t.Fatalf("at %d: got %v, want %v", i, ema.Value(), want)
+		}
+		if ema.Ready() != (i+1 >= 10) {
+			t.Fatalf("at %d: ready %v", i, ema.Ready())
+		}
+	}
+}
+
+func TestVWAP(t *testing.T) {
+	tests := []struct {
+		name   string
+		period int
+		trades [][2]float64 // Price and quantity
+		want   float64
+		ready  bool
+	}{
+		{"rolling window drops old trades", 2, [][2]float64{{100, 10}, {110, 1}, {120, 3}}, 117.5, true},
+		{"rolling window not yet full", 3, [][2]float64{{100, 1}, {110, 1}}, 105, false},
+		{"zero period accumulates", 0, [][2]float64{{100, 10}, {110, 1}, {120, 3}}, 1470.0 / 14, true},
+		{"no volume", 2, [][2]float64{{100, 0}, {110, 0}}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vwap := NewVWAP(tt.period)
+			for _, trade := range tt.trades {
+				vwap.Update(trade[0], trade[1])
+			}
+			if !approx(vwap.Value(), tt.want) || vwap.Ready() != tt.ready {
+				t.Fatalf("got %v ready %v, want %v ready %v", vwap.Value(), vwap.Ready(), tt.want, tt.ready)
+			}
+		})
+	}
+}
+
+func TestNonPositivePeriods(t *testing.T) {
+	// Periods below 1 behave as a window of one value
+	for _, period := range []int{0, -5} {
+		sma, ema, variance := NewSMA(period), NewEMA(period), NewRollingVariance(period)
+		rsi, atr := NewRSI(period), NewATR(period)
+		for _, price := range []float64{100, 104} {
+			sma.Update(price)
+			ema.Update(price)
+			variance.Update(price)
+			rsi.Update(price)
+			atr.Update(price+1, price-1, price)
+		}
+		if sma.Value() != 104 || !sma.Ready() {
+			t.Errorf("SMA(%d) = %v, want the last value", period, sma.Value())
+		}
+		if ema.Value() != 104 || !ema.Ready() {
+			t.Errorf("EMA(%d) = %v, want the last value", period, ema.Value())
+		}
+		if variance.Variance() != 0 || variance.Mean() != 104 || !variance.Ready() {
+			t.Errorf("RollingVariance(%d) = %v around %v, want 0 around the last value", period, variance.Variance(), variance.Mean())
+		}
+		if rsi.Value() != 100 || !rsi.Ready() {
+			t.Errorf("RSI(%d) = %v, want 100 after a rise", period, rsi.Value())
+		}
+		if atr.Value() != 5 || !atr.Ready() {
+			t.Errorf("ATR(%d) = %v, want the last true range", period, atr.Value())
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package indicators
+
+// RSI is the Relative Strength Index with Wilder smoothing
+type RSI struct {
+	period    int
+	count     int // Number of price changes seen, capped at period
+	avgGain   float64
+	avgLoss   float64
+	prevPrice float64
+	started   bool
+}
+
+// NewRSI creates a relative strength index over period price changes
+func NewRSI(period int) *RSI {
+	if period < 1 {
+		period = 1
+	}
+	return &RSI{period: period}
+}
+
+// Update adds a new price
+func (r *RSI) Update(price float64) {
+	if !r.started {
+		r.prevPrice = price
+		r.started = true
+		return
+	}
+
+	change := price - r.prevPrice
+	r.prevPrice = price
+
+	gain, loss := 0.0, 0.0
+	if change > 0 {
+		gain = change
+	} else {
+		loss = -change
+	}
+
+	n := float64(r.period)
+	if r.count < r.period {
+		// Simple average until the first full period
+		r.count++
+		n = float64(r.count)
+	}
+	r.avgGain += (gain - r.avgGain) / n
+	r.avgLoss += (loss - r.avgLoss) / n
+}
+
+// Value returns the index in the range [0, 100]
+func (r *RSI) Value() float64 {
+	if r.avgLoss == 0 {
+		if r.avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	rs := r.avgGain / r.avgLoss
+	return 100 - 100/(1+rs)
+}
+
+// Ready reports whether at least period price changes have been seen
+func (r *RSI) Ready() bool {
+	return r.count >= r.period
+}
+
+// MACD is the Moving Average Convergence Divergence oscillator
+type MACD struct {
+	fast   *EMA
+	slow   *EMA
+	signal *EMA
+}
+
+// NewMACD creates a MACD with the given fast, slow and signal periods
+func NewMACD(fast, slow, signal int) *MACD {
+	return &MACD{
+		fast:   NewEMA(fast),
+		slow:   NewEMA(slow),
+		signal: NewEMA(signal),
+	}
+}
+
+// Update adds a new price
+func (m *MACD) Update(price float64) {
+	m.fast.Update(price)
+	m.slow.Update(price)
+	m.signal.Update(m.Value())
+}
+
+// Value returns the MACD line (fast EMA minus slow EMA)
+func (m *MACD) Value() float64 {
+	return m.fast.Value() - m.slow.Value()
+}
+
+// Signal returns the signal line
+func (m *MACD) Signal() float64 {
+	return m.signal.Value()
+}
+
+// Histogram returns the MACD line minus the signal line
+func (m *MACD) Histogram() float64 {
+	return m.Value() - m.Signal()
+}
+
+// Ready reports whether the slow average and signal line are primed
+func (m *MACD) Ready() bool {
+	return m.slow.Ready() && m.signal.Ready()
+}
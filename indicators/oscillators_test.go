@@ -0,0 +1,78 @@
+package indicators
+
+import "testing"
+
+func TestRSIMatchesBruteForce(t *testing.T) {
+	prices := randomWalk(10000, 100)
+	const period = 14
+	rsi := NewRSI(period)
+	var gains, losses []float64
+	avgGain, avgLoss := 0.0, 0.0
+	for i, price := range prices {
+		rsi.Update(price)
+		if i == 0 {
+			continue
+		}
+
+		change := price - prices[i-1]
+		gain, loss := max(change, 0), max(-change, 0)
+		gains, losses = append(gains, gain), append(losses, loss)
+		if i <= period {
+			avgGain, _ = windowStats(gains)
+			avgLoss, _ = windowStats(losses)
+		} else {
+			avgGain = (avgGain*(period-1) + gain) / period
+			avgLoss = (avgLoss*(period-1) + loss) / period
+		}
+
+		if want := 100 - 100/(1+avgGain/avgLoss); !approx(rsi.Value(), want) {
+			t.Fatalf("at %d: got %v, want %v", i, rsi.Value(), want)
+		}
+		if rsi.Ready() != (i >= period) {
+			t.Fatalf("at %d: ready %v", i, rsi.Ready())
+		}
+	}
+}
+
+func TestRSIExtremes(t *testing.T) {
+	tests := []struct {
+		name   string
+		prices []float64
+		want   float64
+	}{
+		{"only gains", []float64{1, 2, 3, 4}, 100},
+		{"only losses", []float64{4, 3, 2, 1}, 0},
+		{"flat", []float64{2, 2, 2, 2}, 50},
+		{"no change seen", []float64{2}, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rsi := NewRSI(3)
+			for _, price := range tt.prices {
+				rsi.Update(price)
+			}
+			if rsi.Value() != tt.want {
+				t.Fatalf("got %v, want %v", rsi.Value(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMACD(t *testing.T) {
+	prices := randomWalk(500, 100)
+	macd := NewMACD(12, 26, 9)
+	fast, slow, signal := NewEMA(12), NewEMA(26), NewEMA(9)
+	for _, price := range prices {
+		macd.Update(price)
+		fast.Update(price)
+		slow.Update(price)
+		signal.Update(fast.Value() - slow.Value())
+	}
+	if !approx(macd.Value(), fast.Value()-slow.Value()) || !approx(macd.Signal(), signal.Value()) {
+		t.Fatalf("got %v signal %v, want %v signal %v", macd.Value(), macd.Signal(), fast.Value()-slow.Value(), signal.Value())
+	}
+	if !approx(macd.Histogram(), macd.Value()-macd.Signal()) || !macd.Ready() {
+		t.Fatalf("histogram %v ready %v", macd.Histogram(), macd.Ready())
+	}
+}
@@ -0,0 +1,35 @@
+package indicators
+
+// ring is a fixed-capacity FIFO buffer used by windowed indicators
+type ring struct {
+	buf  []float64
+	head int // index of the oldest value
+	size int
+}
+
+// newRing creates a ring buffer holding at most capacity values
+func newRing(capacity int) *ring {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &ring{buf: make([]float64, capacity)}
+}
+
+// push appends v and returns the evicted value once the buffer is full
+func (r *ring) push(v float64) (evicted float64, full bool) {
+	if r.size < len(r.buf) {
+		r.buf[(r.head+r.size)%len(r.buf)] = v
+		r.size++
+		return 0, false
+	}
+
+	evicted = r.buf[r.head]
+	r.buf[r.head] = v
+	r.head = (r.head + 1) % len(r.buf)
+	return evicted, true
+}
+
+// full reports whether the buffer holds capacity values
+func (r *ring) full() bool {
+	return r.size == len(r.buf)
+}
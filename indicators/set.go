@@ -0,0 +1,201 @@
+package indicators
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Set is a collection of named indicators built from JSON specs and updated from trades.
+//
+// Each spec is an object with a "type" and its parameters, e.g.
+//
+//	{"bb": {"type": "bollinger", "period": 20, "stdDev": 2}, "rsi": {"type": "rsi", "period": 14}}
+//
+// Supported types are sma, ema, stddev, zscore, rsi, vwap and atr (with "period"),
+// bollinger ("period", "stdDev") and macd ("fast", "slow", "signal"). An optional
+// "input" of "price" (default), "qty" or "return" (log return) selects what the
+// indicator is fed; vwap always uses price and qty. Values are exposed by name, with
+// bollinger adding name.upper, name.middle and name.lower, and macd adding
+// name.signal and name.histogram. Fed bars through UpdateBar, atr uses their
+// high, low and close.
+type Set struct {
+	items     []setItem
+	lastPrice float64
+}
+
+// setItem is a named indicator in a Set
+type setItem struct {
+	name   string
+	input  string
+	update func(price, qty float64)
+	bar    func(high, low, close float64) // Replaces update for bars when set
+	values func(name string, out map[string]float64)
+	ready  func() bool
+}
+
+// NewSet creates a set from specs keyed by indicator name
+func NewSet(specs map[string]interface{}) (*Set, error) {
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	set := &Set{}
+	for _, name := range names {
+		spec, ok := specs[name].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("indicator %q must be an object", name)
+		}
+		item, err := newSetItem(name, spec)
+		if err != nil {
+			return nil, fmt.Errorf("indicator %q: %w", name, err)
+		}
+		set.items = append(set.items, item)
+	}
+	return set, nil
+}
+
+// newSetItem builds one indicator from its spec
+func newSetItem(name string, spec map[string]interface{}) (setItem, error) {
+	item := setItem{name: name, input: stringSpec(spec, "input", "price")}
+	if item.input != "price" && item.input != "qty" && item.input != "return" {
+		return item, fmt.Errorf("unknown input %q", item.input)
+	}
+	period := intSpec(spec, "period", 14)
+	single := func(v func() float64) func(string, map[string]float64) {
+		return func(name string, out map[string]float64) { out[name] = v() }
+	}
+
+	switch kind := stringSpec(spec, "type", ""); kind {
+	case "sma":
+		ind := NewSMA(period)
+		item.update, item.values, item.ready = func(v, _ float64) { ind.Update(v) }, single(ind.Value), ind.Ready
+	case "ema":
+		ind := NewEMA(period)
+		item.update, item.values, item.ready = func(v, _ float64) { ind.Update(v) }, single(ind.Value), ind.Ready
+	case "stddev":
+		ind := NewRollingVariance(period)
+		item.update, item.values, item.ready = func(v, _ float64) { ind.Update(v) }, single(ind.StdDev), ind.Ready
+	case "zscore":
+		ind := NewZScore(period)
+		item.update, item.values, item.ready = func(v, _ float64) { ind.Update(v) }, single(ind.Value), ind.Ready
+	case "rsi":
+		ind := NewRSI(period)
+		item.update, item.values, item.ready = func(v, _ float64) { ind.Update(v) }, single(ind.Value), ind.Ready
+	case "atr":
+		ind := NewATR(period)
+		item.update, item.values, item.ready = func(v, _ float64) { ind.Update(v, v, v) }, single(ind.Value), ind.Ready
+		if item.input == "price" {
+			item.bar = ind.Update
+		}
+	case "vwap":
+		ind := NewVWAP(period)
+		item.input = "price" // Fed price and qty regardless of input
+		item.update, item.values, item.ready = ind.Update, single(ind.Value), ind.Ready
+	case "bollinger":
+		ind := NewBollinger(period, floatSpec(spec, "stdDev", 2))
+		item.update = func(v, _ float64) { ind.Update(v) }
+		item.values = func(name string, out map[string]float64) {
+			out[name+".upper"] = ind.Upper()
+			out[name+".middle"] = ind.Middle()
+			out[name+".lower"] = ind.Lower()
+		}
+		item.ready = ind.Ready
+	case "macd":
+		ind := NewMACD(intSpec(spec, "fast", 12), intSpec(spec, "slow", 26), intSpec(spec, "signal", 9))
+		item.update = func(v, _ float64) { ind.Update(v) }
+		item.values = func(name string, out map[string]float64) {
+			out[name] = ind.Value()
+			out[name+".signal"] = ind.Signal()
+			out[name+".histogram"] = ind.Histogram()
+		}
+		item.ready = ind.Ready
+	default:
+		return item, fmt.Errorf("unknown type %q", kind)
+	}
+	return item, nil
+}
+
+// Update feeds a trade to every indicator
+func (s *Set) Update(price, qty float64) {
+	s.UpdateBar(price, price, price, qty)
+}
+
+// UpdateBar feeds a bar to every indicator; those taking one value get the close
+func (s *Set) UpdateBar(high, low, close, volume float64) {
+	logReturn := 0.0
+	if s.lastPrice > 0 && close > 0 {
+		logReturn = math.Log(close / s.lastPrice)
+	}
+
+	for _, item := range s.items {
+		switch {
+		case item.input == "qty":
+			item.update(volume, 0)
+		case item.input == "return":
+			if s.lastPrice > 0 {
+				item.update(logReturn, 0)
+			}
+		case item.bar != nil:
+			item.bar(high, low, close)
+		default:
+			item.update(close, volume)
+		}
+	}
+	s.lastPrice = close
+}
+
+// Values writes the current indicator values into out
+func (s *Set) Values(out map[string]float64) {
+	for _, item := range s.items {
+		item.values(item.name, out)
+	}
+}
+
+// Names returns the value names the set exposes
+func (s *Set) Names() []string {
+	values := make(map[string]float64)
+	s.Values(values)
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Ready reports whether every indicator is primed
+func (s *Set) Ready() bool {
+	for _, item := range s.items {
+		if !item.ready() {
+			return false
+		}
+	}
+	return true
+}
+
+// floatSpec reads a numeric spec parameter, falling back to def
+func floatSpec(spec map[string]interface{}, key string, def float64) float64 {
+	if v, ok := spec[key].(float64); ok {
+		return v
+	}
+	return def
+}
+
+// intSpec reads an integer spec parameter, falling back to def
+func intSpec(spec map[string]interface{}, key string, def int) int {
+	if v, ok := spec[key].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+// stringSpec reads a string spec parameter, falling back to def
+func stringSpec(spec map[string]interface{}, key string, def string) string {
+	if v, ok := spec[key].(string); ok {
+		return v
+	}
+	return def
+}
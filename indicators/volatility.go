@@ -0,0 +1,234 @@
+package indicators
+
+import "math"
+
+// RollingVariance tracks mean and population variance over a window
+// using Welford's algorithm, updated in constant time per value
+type RollingVariance struct {
+	window *ring
+	mean   float64
+	m2     float64
+}
+
+// NewRollingVariance creates a rolling variance over period values
+func NewRollingVariance(period int) *RollingVariance {
+	return &RollingVariance{window: newRing(period)}
+}
+
+// Update adds a new value, replacing the oldest one once the window is full
+func (rv *RollingVariance) Update(v float64) {
+	old, evicted := rv.window.push(v)
+	if !evicted {
+		delta := v - rv.mean
+		rv.mean += delta / float64(rv.window.size)
+		rv.m2 += delta * (v - rv.mean)
+		return
+	}
+
+	oldMean := rv.mean
+	rv.mean += (v - old) / float64(rv.window.size)
+	rv.m2 += (v - old) * (v - rv.mean + old - oldMean)
+	if rv.m2 < 0 {
+		rv.m2 = 0 // Guard against floating point drift
+	}
+}
+
+// Mean returns the window mean
+func (rv *RollingVariance) Mean() float64 {
+	return rv.mean
+}
+
+// Variance returns the population variance of the window
+func (rv *RollingVariance) Variance() float64 {
+	if rv.window.size == 0 {
+		return 0
+	}
+	return rv.m2 / float64(rv.window.size)
+}
+
+// StdDev returns the population standard deviation of the window
+func (rv *RollingVariance) StdDev() float64 {
+	return math.Sqrt(rv.Variance())
+}
+
+// Ready reports whether the window is full
+func (rv *RollingVariance) Ready() bool {
+	return rv.window.full()
+}
+
+// Bollinger computes Bollinger Bands around a rolling mean
+type Bollinger struct {
+	stats *RollingVariance
+	width float64
+}
+
+// NewBollinger creates bands stdDev standard deviations around a period-long mean
+func NewBollinger(period int, stdDev float64) *Bollinger {
+	return &Bollinger{
+		stats: NewRollingVariance(period),
+		width: stdDev,
+	}
+}
+
+// Update adds a new price to the bands
+func (b *Bollinger) Update(price float64) {
+	b.stats.Update(price)
+}
+
+// Middle returns the moving average
+func (b *Bollinger) Middle() float64 {
+	return b.stats.Mean()
+}
+
+// Upper returns the upper band
+func (b *Bollinger) Upper() float64 {
+	return b.stats.Mean() + b.width*b.stats.StdDev()
+}
+
+// Lower returns the lower band
+func (b *Bollinger) Lower() float64 {
+	return b.stats.Mean() - b.width*b.stats.StdDev()
+}
+
+// Ready reports whether the window is full
+func (b *Bollinger) Ready() bool {
+	return b.stats.Ready()
+}
+
+// ZScore measures how many standard deviations the last value is from the window mean
+type ZScore struct {
+	stats *RollingVariance
+	last  float64
+}
+
+// NewZScore creates a z-score over period values
+func NewZScore(period int) *ZScore {
+	return &ZScore{stats: NewRollingVariance(period)}
+}
+
+// Update adds a new value
+func (z *ZScore) Update(v float64) {
+	z.stats.Update(v)
+	z.last = v
+}
+
+// Value returns the z-score of the last value, or 0 when the window is flat
+func (z *ZScore) Value() float64 {
+	std := z.stats.StdDev()
+	if std == 0 {
+		return 0
+	}
+	return (z.last - z.stats.Mean()) / std
+}
+
+// Ready reports whether the window is full
+func (z *ZScore) Ready() bool {
+	return z.stats.Ready()
+}
+
+// ATR is the Average True Range with Wilder smoothing
+type ATR struct {
+	period    int
+	count     int
+	value     float64
+	prevClose float64
+}
+
+// NewATR creates an average true range over period bars
+func NewATR(period int) *ATR {
+	if period < 1 {
+		period = 1
+	}
+	return &ATR{period: period}
+}
+
+// Update adds a bar to the average
+func (a *ATR) Update(high, low, close float64) {
+	tr := high - low
+	if a.count > 0 {
+		tr = math.Max(tr, math.Max(math.Abs(high-a.prevClose), math.Abs(low-a.prevClose)))
+	}
+	a.prevClose = close
+
+	if a.count < a.period {
+		// Simple average until the first full period
+		a.count++
+		a.value += (tr - a.value) / float64(a.count)
+		return
+	}
+	a.value += (tr - a.value) / float64(a.period)
+}
+
+// Value returns the current average true range
+func (a *ATR) Value() float64 {
+	return a.value
+}
+
+// Ready reports whether at least period bars have been seen
+func (a *ATR) Ready() bool {
+	return a.count >= a.period
+}
+
+// RollingRegression fits y = Alpha + Beta*x by ordinary least squares over a window,
+// updating co-moments in constant time per pair
+type RollingRegression struct {
+	xs    *ring
+	ys    *ring
+	meanX float64
+	meanY float64
+	m2x   float64 // Sum of squared deviations of x
+	cxy   float64 // Sum of co-deviations of x and y
+}
+
+// NewRollingRegression creates a rolling regression over period pairs
+func NewRollingRegression(period int) *RollingRegression {
+	return &RollingRegression{xs: newRing(period), ys: newRing(period)}
+}
+
+// Update adds an (x, y) pair, removing the oldest one once the window is full
+func (r *RollingRegression) Update(x, y float64) {
+	oldX, evicted := r.xs.push(x)
+	oldY, _ := r.ys.push(y)
+	n := float64(r.xs.size) // Window size including the new pair
+
+	if evicted {
+		// Remove the oldest pair, leaving n-1
+		if n == 1 {
+			r.meanX, r.meanY, r.m2x, r.cxy = 0, 0, 0, 0
+		} else {
+			meanX := (r.meanX*n - oldX) / (n - 1)
+			meanY := (r.meanY*n - oldY) / (n - 1)
+			r.m2x -= (oldX - meanX) * (oldX - r.meanX)
+			r.cxy -= (oldX - meanX) * (oldY - r.meanY)
+			r.meanX, r.meanY = meanX, meanY
+		}
+	}
+
+	// Add the new pair as the n-th
+	dx := x - r.meanX
+	r.meanX += dx / n
+	r.meanY += (y - r.meanY) / n
+	r.m2x += dx * (x - r.meanX)
+	r.cxy += dx * (y - r.meanY)
+	if r.m2x < 0 {
+		r.m2x = 0 // Guard against floating point drift
+	}
+}
+
+// Beta returns the slope, or 0 while x has no variance
+func (r *RollingRegression) Beta() float64 {
+	if r.m2x == 0 {
+		return 0
+	}
+	return r.cxy / r.m2x
+}
+
+// Alpha returns the intercept
+func (r *RollingRegression) Alpha() float64 {
+	return r.meanY - r.Beta()*r.meanX
+}
+
+// Ready reports whether the window is full
+func (r *RollingRegression) Ready() bool {
+	return r.xs.full()
+}
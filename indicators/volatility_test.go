@@ -0,0 +1,136 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+)
+
+// windowStats returns the mean and population variance of values
+func windowStats(values []float64) (float64, float64) {
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, variance / float64(len(values))
+}
+
+func TestRollingVarianceMatchesBruteForce(t *testing.T) {
+	prices := randomWalk(100000, 10000)
+	for _, period := range []int{1, 2, 50, 500} {
+		rv := NewRollingVariance(period)
+		for i, price := range prices {
+			rv.Update(price)
+			mean, variance := windowStats(prices[max(0, i+1-period) : i+1])
+			// Rounding error scales with the square of the values, not with the variance
+			if !approx(rv.Mean(), mean) || math.Abs(rv.Variance()-variance) > 1e-10*mean*mean {
+				t.Fatalf("period %d at %d: got mean %v variance %v, want %v %v", period, i, rv.Mean(), rv.Variance(), mean, variance)
+			}
+		}
+	}
+}
+
+func TestZScoreAndBollinger(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 10}
+	z, bands := NewZScore(4), NewBollinger(4, 2)
+	for _, v := range values {
+		z.Update(v)
+		bands.Update(v)
+	}
+	mean, variance := windowStats(values[1:])
+	std := math.Sqrt(variance)
+	if !approx(z.Value(), (10-mean)/std) {
+		t.Fatalf("z-score %v, want %v", z.Value(), (10-mean)/std)
+	}
+	if !approx(bands.Middle(), mean) || !approx(bands.Upper(), mean+2*std) || !approx(bands.Lower(), mean-2*std) {
+		t.Fatalf("bands %v %v %v around %v ± 2*%v", bands.Lower(), bands.Middle(), bands.Upper(), mean, std)
+	}
+
+	flat := NewZScore(3)
+	for i := 0; i < 3; i++ {
+		flat.Update(5)
+	}
+	if flat.Value() != 0 {
+		t.Fatalf("z-score of a flat window %v, want 0", flat.Value())
+	}
+}
+
+func TestATRMatchesBruteForce(t *testing.T) {
+	closes := randomWalk(10000, 100)
+	const period = 14
+	atr := NewATR(period)
+	var ranges []float64
+	want := 0.0
+	for i, c := range closes {
+		high, low := c*1.002, c*0.997
+		atr.Update(high, low, c)
+
+		tr := high - low
+		if i > 0 {
+			prev := closes[i-1]
+			tr = math.Max(tr, math.Max(math.Abs(high-prev), math.Abs(low-prev)))
+		}
+		ranges = append(ranges, tr)
+		if i < period {
+			want, _ = windowStats(ranges)
+		} else {
+			want = (want*(period-1) + tr) / period
+		}
+
+		if !approx(atr.Value(), want) {
+			t.Fatalf("at %d: got %v, want %v", i, atr.Value(), want)
+		}
+		if atr.Ready() != (i+1 >= period) {
+			t.Fatalf("at %d: ready %v", i, atr.Ready())
+		}
+	}
+}
+
+func TestRollingRegressionMatchesBruteForce(t *testing.T) {
+	xs := randomWalk(100000, 100)
+	ys := make([]float64, len(xs))
+	for i, x := range xs {
+		ys[i] = 5 + 1.5*x + math.Sin(float64(i))
+	}
+
+	for _, period := range []int{10, 30, 500} {
+		reg := NewRollingRegression(period)
+		for i := range xs {
+			reg.Update(xs[i], ys[i])
+			start := max(0, i+1-period)
+			meanX, varX := windowStats(xs[start : i+1])
+			meanY, _ := windowStats(ys[start : i+1])
+			cov := 0.0
+			for j := start; j <= i; j++ {
+				cov += (xs[j] - meanX) * (ys[j] - meanY)
+			}
+			cov /= float64(i + 1 - start)
+
+			if varX < 1e-9*meanX*meanX {
+				continue // Too little spread in x for a stable slope
+			}
+			wantBeta := cov / varX
+			if math.Abs(reg.Beta()-wantBeta) > 1e-6*math.Max(1, math.Abs(wantBeta)) {
+				t.Fatalf("period %d at %d: beta %v, want %v", period, i, reg.Beta(), wantBeta)
+			}
+			if wantAlpha := meanY - wantBeta*meanX; math.Abs(reg.Alpha()-wantAlpha) > 1e-6*math.Max(meanY, math.Abs(wantAlpha)) {
+				t.Fatalf("period %d at %d: alpha %v, want %v", period, i, reg.Alpha(), wantAlpha)
+			}
+			if reg.Ready() != (i+1 >= period) {
+				t.Fatalf("period %d at %d: ready %v", period, i, reg.Ready())
+			}
+		}
+	}
+
+	flat := NewRollingRegression(3)
+	for i := 0; i < 3; i++ {
+		flat.Update(2, float64(i))
+	}
+	if flat.Beta() != 0 || flat.Alpha() != 1 {
+		t.Fatalf("regression on constant x: beta %v alpha %v, want 0 and the mean of y", flat.Beta(), flat.Alpha())
+	}
+}
@@ -28,6 +28,8 @@ func main() {
 	app.Get("/api/trades", handlers.GetTradesHandler)
 	app.Get("/api/hours", handlers.GetHoursHandler)
 	app.Post("/api/backtest", handlers.RunBacktestHandler)
+	app.Post("/api/backtest/decisions", handlers.DecisionLogHandler)
+	app.Post("/api/features", handlers.ExportFeaturesHandler)
 	app.Get("/health", handlers.HealthHandler)
 
 	log.Printf("Server starting on port 8080...")
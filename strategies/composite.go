@@ -0,0 +1,329 @@
+package strategies
+
+import (
+	"fmt"
+	"hft-backtester/backtester"
+	"sort"
+	"time"
+)
+
+func init() {
+	Register("composite", func(params map[string]interface{}) (backtester.Strategy, error) {
+		mode := stringParam(params, "mode", "vote")
+		if mode != "vote" && mode != "weighted" && mode != "priority" {
+			return nil, fmt.Errorf("unknown composite mode %q", mode)
+		}
+
+		specs, _ := params["children"].([]interface{})
+		if len(specs) == 0 {
+			return nil, fmt.Errorf("composite strategy needs children")
+		}
+		composite := NewCompositeStrategy(mode, floatParam(params, "threshold", 0.5))
+		for i, item := range specs {
+			spec, _ := item.(map[string]interface{})
+			childParams, _ := spec["params"].(map[string]interface{})
+			kind := stringParam(spec, "strategy", "")
+			child, err := New(kind, childParams)
+			if err != nil {
+				closeStrategy(composite)
+				return nil, fmt.Errorf("child %d: %w", i, err)
+			}
+			composite.Add(stringParam(spec, "name", fmt.Sprintf("%s#%d", kind, i)), child, floatParam(spec, "weight", 1))
+		}
+		return composite, nil
+	})
+}
+
+// CompositeStrategy combines the signals of several child strategies into one target position.
+//
+// Each child is tracked as a virtual position in [-1, 1] driven by its signals, with
+// the same semantics the engine applies to a real position. The children are combined by:
+//   - vote: long (short) when more than threshold of the children are long (short)
+//   - weighted: the weighted average of the child targets
+//   - priority: the target of the first child that is not flat
+//
+// Only signal actions are combined; orders attached by children are ignored.
+// Every optional hook is forwarded to the children implementing it: fills and rejects
+// of the combined orders, bars of the timeframes they subscribe to and their timers.
+type CompositeStrategy struct {
+	mode      string
+	threshold float64
+	children  []*compositeChild
+	target    float64 // Last combined target sent to the engine
+	resync    bool    // The last target was rejected; send it again
+}
+
+// compositeChild is a child strategy with its virtual position and attribution
+type compositeChild struct {
+	name        string
+	strategy    backtester.Strategy
+	weight      float64
+	target      float64
+	attribution *backtester.Attribution
+}
+
+// NewCompositeStrategy creates an empty composite strategy
+func NewCompositeStrategy(mode string, threshold float64) *CompositeStrategy {
+	return &CompositeStrategy{mode: mode, threshold: threshold}
+}
+
+// Add appends a child strategy; earlier children have priority
+func (c *CompositeStrategy) Add(name string, strategy backtester.Strategy, weight float64) {
+	c.children = append(c.children, &compositeChild{
+		name:        name,
+		strategy:    strategy,
+		weight:      weight,
+		attribution: &backtester.Attribution{Signals: make(map[string]int)},
+	})
+}
+
+// OnStart implements backtester.Starter
+func (c *CompositeStrategy) OnStart(config backtester.Config, warmup []backtester.ChartPoint) {
+	for _, child := range c.children {
+		if starter, ok := child.strategy.(backtester.Starter); ok {
+			starter.OnStart(config, warmup)
+		}
+	}
+}
+
+// Children returns the child strategies in priority order
+func (c *CompositeStrategy) Children() []backtester.Strategy {
+	children := make([]backtester.Strategy, len(c.children))
+	for i, child := range c.children {
+		children[i] = child.strategy
+	}
+	return children
+}
+
+// OnTick implements backtester.Strategy
+func (c *CompositeStrategy) OnTick(point backtester.ChartPoint) backtester.Signal {
+	for _, child := range c.children {
+		child.update(child.strategy.OnTick(point))
+	}
+	return c.signal(point.Price, point.Timestamp())
+}
+
+// Timeframes implements backtester.BarListener with the timeframes of all children
+func (c *CompositeStrategy) Timeframes() []time.Duration {
+	seen := make(map[time.Duration]bool)
+	var timeframes []time.Duration
+	for _, child := range c.children {
+		if listener, ok := child.strategy.(backtester.BarListener); ok {
+			for _, timeframe := range listener.Timeframes() {
+				if !seen[timeframe] {
+					seen[timeframe] = true
+					timeframes = append(timeframes, timeframe)
+				}
+			}
+		}
+	}
+	sort.Slice(timeframes, func(i, j int) bool { return timeframes[i] < timeframes[j] })
+	return timeframes
+}
+
+// OnBar implements backtester.BarListener, passing the bar to the children subscribed to its timeframe
+func (c *CompositeStrategy) OnBar(bar backtester.Bar) backtester.Signal {
+	for _, child := range c.children {
+		listener, ok := child.strategy.(backtester.BarListener)
+		if !ok {
+			continue
+		}
+		for _, timeframe := range listener.Timeframes() {
+			if timeframe == bar.Timeframe {
+				child.update(listener.OnBar(bar))
+				break
+			}
+		}
+	}
+	return c.signal(bar.Close, time.UnixMilli(bar.End))
+}
+
+// TimerInterval implements backtester.TimerListener with the greatest common divisor of the
+// children's intervals, so that every child timer falls on a composite timer
+func (c *CompositeStrategy) TimerInterval() time.Duration {
+	var interval time.Duration
+	for _, child := range c.children {
+		if listener, ok := child.strategy.(backtester.TimerListener); ok && listener.TimerInterval() > 0 {
+			a, b := interval, listener.TimerInterval()
+			for b != 0 {
+				a, b = b, a%b
+			}
+			interval = a
+		}
+	}
+	return interval
+}
+
+// OnTimer implements backtester.TimerListener, calling each child timer on its own interval
+func (c *CompositeStrategy) OnTimer(now time.Time) {
+	for _, child := range c.children {
+		if listener, ok := child.strategy.(backtester.TimerListener); ok && listener.TimerInterval() > 0 {
+			if now.Equal(now.Truncate(listener.TimerInterval())) {
+				listener.OnTimer(now)
+			}
+		}
+	}
+}
+
+// OnFill implements backtester.FillListener
+func (c *CompositeStrategy) OnFill(trade *backtester.Trade) {
+	for _, child := range c.children {
+		if listener, ok := child.strategy.(backtester.FillListener); ok {
+			listener.OnFill(trade)
+		}
+	}
+}
+
+// update applies a child signal to the child's virtual position
+func (child *compositeChild) update(signal backtester.Signal) {
+	if signal.Action != "HOLD" {
+		child.attribution.Signals[signal.Action]++
+	}
+	child.target = nextTarget(child.target, signal)
+}
+
+// signal combines the child targets, returning a TARGET signal if the combined target changed
+func (c *CompositeStrategy) signal(price float64, at time.Time) backtester.Signal {
+	signal := backtester.Signal{Action: "HOLD", Price: price, Time: at}
+	target := c.combine()
+	if target == c.target && !c.resync {
+		return signal
+	}
+
+	if target != c.target {
+		for _, child := range c.children {
+			if child.target*target > 0 || (child.target == 0 && target == 0) {
+				child.attribution.Aligned++
+			}
+		}
+	}
+	c.target = target
+	c.resync = false
+	signal.Action = "TARGET"
+	signal.Target = target
+	signal.Reason = "composite " + c.mode
+	signal.Values = make(map[string]float64, len(c.children))
+	for _, child := range c.children {
+		signal.Values[child.name] = child.target
+	}
+	return signal
+}
+
+// OnReject implements backtester.RejectListener
+func (c *CompositeStrategy) OnReject(order *backtester.Order, err error) {
+	c.resync = true
+	for _, child := range c.children {
+		if listener, ok := child.strategy.(backtester.RejectListener); ok {
+			listener.OnReject(order, err)
+		}
+	}
+}
+
+// closeStrategy stops the processes of external strategies, including those inside composites
+func closeStrategy(strategy backtester.Strategy) {
+	switch s := strategy.(type) {
+	case *ExternalStrategy:
+		if s.cmd != nil {
+			s.stop(true)
+		}
+	case *CompositeStrategy:
+		for _, child := range s.children {
+			closeStrategy(child.strategy)
+		}
+	}
+}
+
+// nextTarget applies a signal to a virtual position
+func nextTarget(current float64, signal backtester.Signal) float64 {
+	switch signal.Action {
+	case "BUY":
+		if current < 0 {
+			return 0
+		}
+		return 1
+	case "SELL":
+		if current > 0 {
+			return 0
+		}
+		return -1
+	case "EXIT_LONG":
+		if current > 0 {
+			return 0
+		}
+	case "EXIT_SHORT":
+		if current < 0 {
+			return 0
+		}
+	case "TARGET":
+		return signal.Target
+	}
+	return current
+}
+
+// combine merges the child targets according to the mode
+func (c *CompositeStrategy) combine() float64 {
+	switch c.mode {
+	case "weighted":
+		sum, weights := 0.0, 0.0
+		for _, child := range c.children {
+			sum += child.weight * child.target
+			weights += child.weight
+		}
+		if weights == 0 {
+			return 0
+		}
+		return sum / weights
+	case "priority":
+		for _, child := range c.children {
+			if child.target != 0 {
+				return child.target
+			}
+		}
+		return 0
+	default:
+		longs, shorts := 0, 0
+		for _, child := range c.children {
+			if child.target > 0 {
+				longs++
+			} else if child.target < 0 {
+				shorts++
+			}
+		}
+		n := float64(len(c.children))
+		if float64(longs)/n > c.threshold {
+			return 1
+		} else if float64(shorts)/n > c.threshold {
+			return -1
+		}
+		return 0
+	}
+}
+
+// Series implements backtester.SeriesPublisher, prefixing child series with the child name
+func (c *CompositeStrategy) Series() map[string]float64 {
+	series := map[string]float64{"target": c.target}
+	for _, child := range c.children {
+		if publisher, ok := child.strategy.(backtester.SeriesPublisher); ok {
+			for name, value := range publisher.Series() {
+				series[child.name+"."+name] = value
+			}
+		}
+	}
+	return series
+}
+
+// OnEnd implements backtester.Finisher and records per-child attribution
+func (c *CompositeStrategy) OnEnd(result *backtester.BacktestResult) {
+	for _, child := range c.children {
+		if finisher, ok := child.strategy.(backtester.Finisher); ok {
+			finisher.OnEnd(result)
+		}
+	}
+
+	if result.Attribution == nil {
+		result.Attribution = make(map[string]*backtester.Attribution, len(c.children))
+	}
+	for _, child := range c.children {
+		result.Attribution[child.name] = child.attribution
+	}
+}
@@ -0,0 +1,310 @@
+package strategies
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// expr is a compiled expression evaluated against variable slots.
+// Booleans are represented as 1 (true) and 0 (false).
+type expr func(vars []float64) float64
+
+// Limits keeping user supplied expressions cheap to compile and evaluate
+const (
+	maxExprLength = 2000
+	maxExprDepth  = 64
+)
+
+// compileExpr parses an expression such as `price < bb.lower && rsi < 30`.
+//
+// It supports numbers, variables resolved through slots, parentheses, the
+// operators || && ! == != < <= > >= + - * / and the functions abs, min and max.
+// Unknown variables are compile errors, so evaluation cannot fail.
+func compileExpr(source string, slots map[string]int) (expr, error) {
+	if len(source) > maxExprLength {
+		return nil, fmt.Errorf("expression longer than %d characters", maxExprLength)
+	}
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{tokens: tokens, slots: slots}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q", p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+// tokenize splits an expression into numbers, identifiers and operators
+func tokenize(source string) ([]string, error) {
+	var tokens []string
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.' || runes[j] == 'e' || runes[j] == 'E' ||
+				((runes[j] == '-' || runes[j] == '+') && (runes[j-1] == 'e' || runes[j-1] == 'E'))) {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case "&&", "||", "==", "!=", "<=", ">=":
+					tokens = append(tokens, two)
+					i += 2
+					continue
+				}
+			}
+			if !strings.ContainsRune("()<>!+-*/,", r) {
+				return nil, fmt.Errorf("unexpected character %q", r)
+			}
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+// exprParser is a recursive descent parser producing closures
+type exprParser struct {
+	tokens []string
+	pos    int
+	depth  int
+	slots  map[string]int
+}
+
+// peek returns the current token or "" at the end
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// expect consumes the given token
+func (p *exprParser) expect(token string) error {
+	if p.peek() != token {
+		return fmt.Errorf("expected %q", token)
+	}
+	p.pos++
+	return nil
+}
+
+// parseBinary parses a left-associative chain of operators from ops over next
+func (p *exprParser) parseBinary(next func() (expr, error), ops map[string]func(a, b float64) float64) (expr, error) {
+	left, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := ops[p.peek()]
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := next()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(vars []float64) float64 { return op(l(vars), r(vars)) }
+	}
+}
+
+// enter counts a level of recursion, failing beyond maxExprDepth. Callers defer leave.
+func (p *exprParser) enter() error {
+	p.depth++
+	if p.depth > maxExprDepth {
+		return fmt.Errorf("expression nested deeper than %d", maxExprDepth)
+	}
+	return nil
+}
+
+// leave undoes enter
+func (p *exprParser) leave() {
+	p.depth--
+}
+
+func (p *exprParser) parseOr() (expr, error) {
+	defer p.leave()
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+
+	// || and && are parsed separately to short-circuit
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(vars []float64) float64 { return truth(l(vars) != 0 || r(vars) != 0) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (expr, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		l, r := left, right
+		left = func(vars []float64) float64 { return truth(l(vars) != 0 && r(vars) != 0) }
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseComparison() (expr, error) {
+	return p.parseBinary(p.parseSum, map[string]func(a, b float64) float64{
+		"==": func(a, b float64) float64 { return truth(a == b) },
+		"!=": func(a, b float64) float64 { return truth(a != b) },
+		"<":  func(a, b float64) float64 { return truth(a < b) },
+		"<=": func(a, b float64) float64 { return truth(a <= b) },
+		">":  func(a, b float64) float64 { return truth(a > b) },
+		">=": func(a, b float64) float64 { return truth(a >= b) },
+	})
+}
+
+func (p *exprParser) parseSum() (expr, error) {
+	return p.parseBinary(p.parseProduct, map[string]func(a, b float64) float64{
+		"+": func(a, b float64) float64 { return a + b },
+		"-": func(a, b float64) float64 { return a - b },
+	})
+}
+
+func (p *exprParser) parseProduct() (expr, error) {
+	return p.parseBinary(p.parseUnary, map[string]func(a, b float64) float64{
+		"*": func(a, b float64) float64 { return a * b },
+		"/": func(a, b float64) float64 { return a / b },
+	})
+}
+
+func (p *exprParser) parseUnary() (expr, error) {
+	switch p.peek() {
+	case "!", "-":
+		// Operator chains such as - - -1 recurse without parentheses
+		defer p.leave()
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		op := p.peek()
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "!" {
+			return func(vars []float64) float64 { return truth(operand(vars) == 0) }, nil
+		}
+		return func(vars []float64) float64 { return -operand(vars) }, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (expr, error) {
+	token := p.peek()
+	if token == "" {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	p.pos++
+
+	if token == "(" {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	}
+
+	if value, err := strconv.ParseFloat(token, 64); err == nil {
+		return func([]float64) float64 { return value }, nil
+	}
+
+	if p.peek() == "(" {
+		return p.parseCall(token)
+	}
+
+	switch token {
+	case "true":
+		return func([]float64) float64 { return 1 }, nil
+	case "false":
+		return func([]float64) float64 { return 0 }, nil
+	}
+	slot, ok := p.slots[token]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable %q", token)
+	}
+	return func(vars []float64) float64 { return vars[slot] }, nil
+}
+
+// parseCall parses the arguments of a function call
+func (p *exprParser) parseCall(name string) (expr, error) {
+	p.pos++ // (
+	var args []expr
+	for p.peek() != ")" {
+		if len(args) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.pos++ // )
+
+	switch {
+	case name == "abs" && len(args) == 1:
+		a := args[0]
+		return func(vars []float64) float64 { return math.Abs(a(vars)) }, nil
+	case name == "min" && len(args) == 2:
+		a, b := args[0], args[1]
+		return func(vars []float64) float64 { return math.Min(a(vars), b(vars)) }, nil
+	case name == "max" && len(args) == 2:
+		a, b := args[0], args[1]
+		return func(vars []float64) float64 { return math.Max(a(vars), b(vars)) }, nil
+	}
+	return nil, fmt.Errorf("unknown function %s with %d arguments", name, len(args))
+}
+
+// truth converts a boolean to 1 or 0
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,106 @@
+package strategies
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompileExpr(t *testing.T) {
+	slots := map[string]int{"price": 0, "bb.lower": 1, "rsi": 2}
+	vars := []float64{95, 96, 25}
+
+	tests := []struct {
+		source string
+		want   float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 - 4 - 3", 3},
+		{"12 / 3 / 2", 2},
+		{"1 + 1 == 2 && 3 > 2", 1},
+		{"1 || 0 && 0", 1},
+		{"price < bb.lower && rsi < 30", 1},
+		{"price > bb.lower || rsi > 30", 0},
+		{"-2 * 3", -6},
+		{"- -2", 2},
+		{"!!1", 1},
+		{"!-1", 0},
+		{"-!0", -1},
+		{"!price", 0},
+		{"2e-3", 0.002},
+		{"1E+2 - 100", 0},
+		{"2e-3-1", -0.998},
+		{".5 + 1.", 1.5},
+		{"1 != 2", 1},
+		{"price!=price", 0},
+		{"!(1 == 2)", 1},
+		{"1 <= 1 && 1 >= 1 && !(1 < 1) && !(1 > 1)", 1},
+		{"abs(-3) + min(1, 2) + max(1, 2)", 6},
+		{"max(price, bb.lower) - min(price, bb.lower)", 1},
+		{"true && !false", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			e, err := compileExpr(tt.source, slots)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := e(vars); got != tt.want {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileExprShortCircuits(t *testing.T) {
+	// The right operand reads a slot beyond vars and would panic if evaluated
+	slots := map[string]int{"missing": 5}
+	for source, want := range map[string]float64{"0 && missing": 0, "1 || missing": 1} {
+		e, err := compileExpr(source, slots)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := e([]float64{0}); got != want {
+			t.Fatalf("%s: got %v, want %v", source, got, want)
+		}
+	}
+}
+
+func TestCompileExprErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"unknown variable", "volume > 1", "unknown variable"},
+		{"unknown function", "sqrt(4)", "unknown function"},
+		{"wrong argument count", "min(1)", "unknown function"},
+		{"unexpected character", "1 % 2", "unexpected character"},
+		{"single ampersand", "1 & 2", "unexpected character"},
+		{"trailing token", "1 2", "unexpected"},
+		{"missing operand", "1 +", "unexpected end"},
+		{"unclosed parenthesis", "(1 + 2", "expected"},
+		{"malformed number", "1.2.3", "unknown variable"},
+		{"too long", strings.Repeat("1+", maxExprLength/2) + "1", "longer than"},
+		{"too deep", strings.Repeat("(", maxExprDepth+1) + "1" + strings.Repeat(")", maxExprDepth+1), "nested deeper"},
+		{"unary chain too deep", strings.Repeat("-", maxExprDepth+1) + "1", "nested deeper"},
+		{"mixed nesting too deep", strings.Repeat("!(", maxExprDepth/2+1) + "1" + strings.Repeat(")", maxExprDepth/2+1), "nested deeper"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileExpr(tt.source, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+
+	if _, err := compileExpr(strings.Repeat("(", maxExprDepth-1)+"1"+strings.Repeat(")", maxExprDepth-1), nil); err != nil {
+		t.Fatalf("nesting within the limit was rejected: %v", err)
+	}
+	if _, err := compileExpr(strings.Repeat("-", maxExprDepth-1)+"1", nil); err != nil {
+		t.Fatalf("unary chain within the limit was rejected: %v", err)
+	}
+}
@@ -0,0 +1,274 @@
+package strategies
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hft-backtester/backtester"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// ExternalStrategyDir is where executables for external strategies must live
+var ExternalStrategyDir = "upload/strategies"
+
+func init() {
+	Register("external", func(params map[string]interface{}) (backtester.Strategy, error) {
+		name := stringParam(params, "command", "")
+		if name == "" || filepath.Base(name) != name || name == "." || name == ".." {
+			return nil, fmt.Errorf("external strategy command must be a file name in %s", ExternalStrategyDir)
+		}
+		timeoutMs := intParam(params, "timeout_ms", 5000)
+		if timeoutMs <= 0 {
+			// The timeout also bounds how long the process gets to exit after the end message
+			return nil, fmt.Errorf("external strategy timeout_ms must be positive")
+		}
+		timeout := time.Duration(timeoutMs) * time.Millisecond
+		strategy, err := NewExternalStrategy(filepath.Join(ExternalStrategyDir, name), stringsParam(params, "args"), timeout)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range stringsParam(params, "timeframes") {
+			timeframe, err := time.ParseDuration(value)
+			if err != nil {
+				strategy.stop(true)
+				return nil, err
+			}
+			strategy.timeframes = append(strategy.timeframes, timeframe)
+		}
+		return strategy, nil
+	})
+}
+
+// ExternalStrategy runs a strategy in a subprocess speaking line-delimited JSON over stdio.
+//
+// The engine writes one message per line to the process stdin:
+//
+//	{"type":"start","config":{...}}
+//	{"type":"tick","point":{"time":...,"price":...}}
+//	{"type":"bar","bar":{"symbol":...,"timeframe":...,"start":...,"open":...}}
+//	{"type":"fill","trade":{...}}
+//	{"type":"reject","order":{...},"error":"..."}
+//	{"type":"end","final_equity":...}
+//
+// Bars are sent for the timeframes given at construction. Every tick and bar
+// must be answered with one line on stdout within the timeout:
+//
+//	{"action":"BUY","reason":"...","values":{"name":value},"series":{"name":value}}
+//
+// The action is one of HOLD, BUY, SELL, EXIT_LONG or EXIT_SHORT; an unknown action
+// counts as garbage.
+// Reason and values are optional and explain the signal in trades and the decision log.
+// Warm-up ticks are sent like any other; orders they produce come back as rejects.
+// If the process crashes, times out or answers garbage, it is killed,
+// the strategy holds for the rest of the run and Err reports the cause.
+type ExternalStrategy struct {
+	cmd        *exec.Cmd
+	stdin      io.WriteCloser
+	lines      chan []byte
+	timeout    time.Duration
+	timeframes []time.Duration
+	series     map[string]float64
+	err        error
+}
+
+// externalMessage is a message sent to the strategy process
+type externalMessage struct {
+	Type        string                 `json:"type"`
+	Config      *backtester.Config     `json:"config,omitempty"`
+	Point       *backtester.ChartPoint `json:"point,omitempty"`
+	Bar         *backtester.Bar        `json:"bar,omitempty"`
+	Trade       *backtester.Trade      `json:"trade,omitempty"`
+	Order       *backtester.Order      `json:"order,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	FinalEquity float64                `json:"final_equity,omitempty"`
+}
+
+// externalReply is the process answer to a tick
+type externalReply struct {
+	Action string             `json:"action"`
+	Reason string             `json:"reason"`
+	Values map[string]float64 `json:"values"`
+	Series map[string]float64 `json:"series"`
+}
+
+// externalActions are the actions a process may reply with
+var externalActions = map[string]bool{"HOLD": true, "BUY": true, "SELL": true, "EXIT_LONG": true, "EXIT_SHORT": true}
+
+// NewExternalStrategy starts the strategy process
+func NewExternalStrategy(command string, args []string, timeout time.Duration) (*ExternalStrategy, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	s := &ExternalStrategy{
+		cmd:     cmd,
+		stdin:   stdin,
+		lines:   make(chan []byte),
+		timeout: timeout,
+	}
+	go s.readLines(stdout)
+	return s, nil
+}
+
+// readLines forwards stdout lines until the process closes it
+func (s *ExternalStrategy) readLines(stdout io.Reader) {
+	defer close(s.lines)
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := append([]byte(nil), scanner.Bytes()...)
+		s.lines <- line
+	}
+}
+
+// Err returns the reason the process was stopped early, if any
+func (s *ExternalStrategy) Err() error {
+	return s.err
+}
+
+// OnStart implements backtester.Starter
+func (s *ExternalStrategy) OnStart(config backtester.Config, warmup []backtester.ChartPoint) {
+	s.send(externalMessage{Type: "start", Config: &config})
+}
+
+// OnTick implements backtester.Strategy
+func (s *ExternalStrategy) OnTick(point backtester.ChartPoint) backtester.Signal {
+	return s.request(externalMessage{Type: "tick", Point: &point}, point)
+}
+
+// Timeframes implements backtester.BarListener
+func (s *ExternalStrategy) Timeframes() []time.Duration {
+	return s.timeframes
+}
+
+// OnBar implements backtester.BarListener
+func (s *ExternalStrategy) OnBar(bar backtester.Bar) backtester.Signal {
+	return s.request(externalMessage{Type: "bar", Bar: &bar}, backtester.ChartPoint{Time: bar.End, Price: bar.Close})
+}
+
+// request sends a market event and waits for the signal in reply
+func (s *ExternalStrategy) request(msg externalMessage, point backtester.ChartPoint) backtester.Signal {
+	hold := backtester.Signal{Action: "HOLD", Price: point.Price, Time: point.Timestamp()}
+	if !s.send(msg) {
+		return hold
+	}
+
+	select {
+	case line, ok := <-s.lines:
+		if !ok {
+			s.fail(errors.New("external strategy exited"))
+			return hold
+		}
+		var reply externalReply
+		if err := json.Unmarshal(line, &reply); err != nil {
+			s.fail(fmt.Errorf("external strategy sent invalid reply: %w", err))
+			return hold
+		}
+		if !externalActions[reply.Action] {
+			s.fail(fmt.Errorf("external strategy sent unknown action %q", reply.Action))
+			return hold
+		}
+		if msg.Type == "tick" {
+			s.series = reply.Series
+		}
+		return backtester.Signal{
+			Action: reply.Action,
+			Price:  point.Price,
+			Time:   point.Timestamp(),
+			Reason: reply.Reason,
+			Values: reply.Values,
+		}
+	case <-time.After(s.timeout):
+		s.fail(fmt.Errorf("external strategy did not reply within %v", s.timeout))
+		return hold
+	}
+}
+
+// Series implements backtester.SeriesPublisher
+func (s *ExternalStrategy) Series() map[string]float64 {
+	return s.series
+}
+
+// OnFill implements backtester.FillListener
+func (s *ExternalStrategy) OnFill(trade *backtester.Trade) {
+	s.send(externalMessage{Type: "fill", Trade: trade})
+}
+
+// OnReject implements backtester.RejectListener
+func (s *ExternalStrategy) OnReject(order *backtester.Order, err error) {
+	s.send(externalMessage{Type: "reject", Order: order, Error: err.Error()})
+}
+
+// OnEnd implements backtester.Finisher and shuts the process down
+func (s *ExternalStrategy) OnEnd(result *backtester.BacktestResult) {
+	if s.send(externalMessage{Type: "end", FinalEquity: result.FinalEquity}) {
+		s.stop(false)
+	}
+}
+
+// send writes a message to the process, reporting whether it is still running
+func (s *ExternalStrategy) send(msg externalMessage) bool {
+	if s.err != nil || s.cmd == nil {
+		return false
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		s.fail(err)
+		return false
+	}
+	if _, err := s.stdin.Write(append(data, '\n')); err != nil {
+		s.fail(fmt.Errorf("external strategy exited: %w", err))
+		return false
+	}
+	return true
+}
+
+// fail records the first error and kills the process
+func (s *ExternalStrategy) fail(err error) {
+	s.err = err
+	s.series = nil
+	s.stop(true)
+}
+
+// stop closes stdin and waits for the process to exit, killing it
+// immediately or after the timeout
+func (s *ExternalStrategy) stop(kill bool) {
+	go func() {
+		// Drain output so the reader goroutine can finish
+		for range s.lines {
+		}
+	}()
+
+	s.stdin.Close()
+	if kill {
+		s.cmd.Process.Kill()
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- s.cmd.Wait() }()
+	select {
+	case <-done:
+	case <-time.After(s.timeout):
+		s.cmd.Process.Kill()
+		<-done
+	}
+	s.cmd = nil
+}
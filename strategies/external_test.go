@@ -0,0 +1,28 @@
+package strategies
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExternalParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]interface{}
+		want   string
+	}{
+		{"missing command", map[string]interface{}{}, "file name"},
+		{"command outside the directory", map[string]interface{}{"command": "../run.sh"}, "file name"},
+		{"zero timeout", map[string]interface{}{"command": "run.sh", "timeout_ms": 0.0}, "timeout_ms"},
+		{"negative timeout", map[string]interface{}{"command": "run.sh", "timeout_ms": -100.0}, "timeout_ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New("external", tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("got %v, want an error about %s", err, tt.want)
+			}
+		})
+	}
+}
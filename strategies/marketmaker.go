@@ -0,0 +1,111 @@
+package strategies
+
+import (
+	"hft-backtester/backtester"
+	"hft-backtester/indicators"
+	"math"
+)
+
+func init() {
+	Register("marketmaker", func(params map[string]interface{}) (backtester.Strategy, error) {
+		return NewMarketMakerStrategy(
+			intParam(params, "fairWindow", 50),
+			floatParam(params, "spreadBps", 10),
+			floatParam(params, "size", 10),
+			floatParam(params, "maxInventory", 50),
+			floatParam(params, "skew", 1),
+		), nil
+	})
+}
+
+// MarketMakerStrategy quotes a bid and an ask around an EMA fair price with resting limit orders.
+//
+// Quotes are skewed against inventory: when long, both quotes shift down to favour
+// selling, and the side that would breach maxInventory is not quoted. Quotes are
+// replaced when the fair price moves by a quarter of the spread or after a fill.
+// They are post-only, so a quote that would cross is rejected and requoted instead
+// of taking liquidity.
+type MarketMakerStrategy struct {
+	fair         *indicators.EMA
+	halfSpread   float64 // Fraction of fair price
+	size         float64 // Quote size in USD
+	maxInventory float64 // Inventory limit in USD
+	skew         float64 // Quote shift in half-spreads at full inventory
+	inventory    float64 // Signed position in base units
+	quotedFair   float64 // Fair price of the current quotes, 0 when requote is needed
+	bid          float64
+	ask          float64
+}
+
+// NewMarketMakerStrategy creates a new market making strategy
+func NewMarketMakerStrategy(fairWindow int, spreadBps, size, maxInventory, skew float64) *MarketMakerStrategy {
+	return &MarketMakerStrategy{
+		fair:         indicators.NewEMA(fairWindow),
+		halfSpread:   spreadBps / 2 / 10000,
+		size:         size,
+		maxInventory: maxInventory,
+		skew:         skew,
+	}
+}
+
+// OnTick implements backtester.Strategy
+func (m *MarketMakerStrategy) OnTick(point backtester.ChartPoint) backtester.Signal {
+	m.fair.Update(point.Price)
+	signal := backtester.Signal{Action: "HOLD", Price: point.Price, Time: point.Timestamp()}
+	if !m.fair.Ready() {
+		return signal
+	}
+
+	fair := m.fair.Value()
+	if m.quotedFair > 0 && math.Abs(fair-m.quotedFair) < m.quotedFair*m.halfSpread/2 {
+		return signal
+	}
+
+	// Inventory as a fraction of the limit, clamped to [-1, 1]
+	ratio := 0.0
+	if m.maxInventory > 0 {
+		ratio = math.Max(-1, math.Min(1, m.inventory*fair/m.maxInventory))
+	}
+	shift := -m.skew * ratio * m.halfSpread
+	m.bid = fair * (1 - m.halfSpread + shift)
+	m.ask = fair * (1 + m.halfSpread + shift)
+	m.quotedFair = fair
+
+	signal.CancelAll = true
+	signal.Reason = "requote around fair price"
+	signal.Values = map[string]float64{"fair": fair, "bid": m.bid, "ask": m.ask, "inventory": m.inventory}
+	if ratio < 1 {
+		signal.Orders = append(signal.Orders, &backtester.Order{Type: backtester.LimitOrder, Qty: m.size / m.bid, Price: m.bid, IsBuy: true, PostOnly: true})
+	}
+	if ratio > -1 {
+		signal.Orders = append(signal.Orders, &backtester.Order{Type: backtester.LimitOrder, Qty: m.size / m.ask, Price: m.ask, IsBuy: false, PostOnly: true})
+	}
+	return signal
+}
+
+// OnFill implements backtester.FillListener
+func (m *MarketMakerStrategy) OnFill(trade *backtester.Trade) {
+	if trade.IsBuy {
+		m.inventory += trade.Qty
+	} else {
+		m.inventory -= trade.Qty
+	}
+	m.quotedFair = 0 // Requote with the new inventory skew
+}
+
+// OnReject implements backtester.RejectListener
+func (m *MarketMakerStrategy) OnReject(order *backtester.Order, err error) {
+	m.quotedFair = 0 // Requote so the missing side is restored
+}
+
+// Series implements backtester.SeriesPublisher
+func (m *MarketMakerStrategy) Series() map[string]float64 {
+	if m.quotedFair == 0 {
+		return nil
+	}
+	return map[string]float64{
+		"fair": m.fair.Value(),
+		"bid":  m.bid,
+		"ask":  m.ask,
+	}
+}
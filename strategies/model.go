@@ -0,0 +1,228 @@
+package strategies
+
+import (
+	"encoding/json"
+	"fmt"
+	"hft-backtester/backtester"
+	"hft-backtester/indicators"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// ModelDir is where model files for the model strategy must live
+var ModelDir = "upload/models"
+
+func init() {
+	Register("model", func(params map[string]interface{}) (backtester.Strategy, error) {
+		name := stringParam(params, "file", "")
+		if name == "" || filepath.Base(name) != name || name == "." || name == ".." {
+			return nil, fmt.Errorf("model file must be a file name in %s", ModelDir)
+		}
+		spec, err := LoadModelSpec(filepath.Join(ModelDir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		// Request parameters override the thresholds stored with the model
+		spec.Thresholds.EnterLong = floatParam(params, "enterLong", spec.Thresholds.EnterLong)
+		spec.Thresholds.ExitLong = floatParam(params, "exitLong", spec.Thresholds.ExitLong)
+		spec.Thresholds.EnterShort = floatParam(params, "enterShort", spec.Thresholds.EnterShort)
+		spec.Thresholds.ExitShort = floatParam(params, "exitShort", spec.Thresholds.ExitShort)
+		return NewModelStrategy(spec)
+	})
+}
+
+// ModelSpec is a linear or logistic model over indicator features, as stored in a model file:
+//
+//	{
+//	  "type": "logistic",
+//	  "features": {"rsi": {"type": "rsi", "period": 14}, "z": {"type": "zscore", "period": 100}},
+//	  "weights": {"rsi": -0.02, "z": -0.8},
+//	  "bias": 1.0,
+//	  "mean": {"rsi": 50}, "std": {"rsi": 10},
+//	  "thresholds": {"enterLong": 0.6, "exitLong": 0.5, "enterShort": 0.4, "exitShort": 0.5}
+//	}
+//
+// Features are indicators.Set specs; weights may also refer to price and qty.
+// Inputs with a mean or std are standardized before weighting.
+type ModelSpec struct {
+	Type       string                 `json:"type"` // linear (default) or logistic
+	Features   map[string]interface{} `json:"features"`
+	Weights    map[string]float64     `json:"weights"`
+	Bias       float64                `json:"bias"`
+	Mean       map[string]float64     `json:"mean"`
+	Std        map[string]float64     `json:"std"`
+	Thresholds ModelThresholds        `json:"thresholds"`
+}
+
+// ModelThresholds map the model score to a target position.
+// A flat model goes long at or above EnterLong and short at or below EnterShort;
+// a long model exits below ExitLong and a short model exits above ExitShort.
+type ModelThresholds struct {
+	EnterLong  float64 `json:"enterLong"`
+	ExitLong   float64 `json:"exitLong"`
+	EnterShort float64 `json:"enterShort"`
+	ExitShort  float64 `json:"exitShort"`
+}
+
+// LoadModelSpec reads a model file, filling in default thresholds for its type
+func LoadModelSpec(path string) (*ModelSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Defaults: a probability for logistic models, a signed score for linear ones
+	spec := &ModelSpec{Thresholds: ModelThresholds{EnterLong: 1, ExitLong: 0, EnterShort: -1, ExitShort: 0}}
+	var probe struct {
+		Type string `json:"type"`
+	}
+	if json.Unmarshal(data, &probe) == nil && probe.Type == "logistic" {
+		spec.Thresholds = ModelThresholds{EnterLong: 0.6, ExitLong: 0.5, EnterShort: 0.4, ExitShort: 0.5}
+	}
+	if err := json.Unmarshal(data, spec); err != nil {
+		return nil, fmt.Errorf("invalid model file: %w", err)
+	}
+	return spec, nil
+}
+
+// ModelStrategy scores each trade with a linear or logistic model and trades the resulting target
+type ModelStrategy struct {
+	set        *indicators.Set
+	logistic   bool
+	bias       float64
+	terms      []modelTerm
+	values     map[string]float64
+	thresholds ModelThresholds
+	symbol     string // Only trades of this symbol are scored; empty accepts all
+	score      float64
+	target     float64
+	resync     bool // The last target was rejected; send it again
+}
+
+// modelTerm is one weighted, standardized model input
+type modelTerm struct {
+	name   string
+	weight float64
+	mean   float64
+	std    float64
+}
+
+// NewModelStrategy creates a strategy from a model spec
+func NewModelStrategy(spec *ModelSpec) (*ModelStrategy, error) {
+	if spec.Type != "" && spec.Type != "linear" && spec.Type != "logistic" {
+		return nil, fmt.Errorf("unknown model type %q", spec.Type)
+	}
+	set, err := indicators.NewSet(spec.Features)
+	if err != nil {
+		return nil, err
+	}
+
+	known := map[string]bool{"price": true, "qty": true}
+	for _, name := range set.Names() {
+		known[name] = true
+	}
+
+	s := &ModelStrategy{
+		set:        set,
+		logistic:   spec.Type == "logistic",
+		bias:       spec.Bias,
+		values:     make(map[string]float64),
+		thresholds: spec.Thresholds,
+	}
+	for name, weight := range spec.Weights {
+		if !known[name] {
+			return nil, fmt.Errorf("weight for unknown feature %q", name)
+		}
+		term := modelTerm{name: name, weight: weight, mean: spec.Mean[name], std: 1}
+		if std, ok := spec.Std[name]; ok {
+			if std <= 0 {
+				return nil, fmt.Errorf("std of feature %q must be positive", name)
+			}
+			term.std = std
+		}
+		s.terms = append(s.terms, term)
+	}
+	if len(s.terms) == 0 {
+		return nil, fmt.Errorf("model has no weights")
+	}
+	return s, nil
+}
+
+// OnStart implements backtester.Starter
+func (s *ModelStrategy) OnStart(config backtester.Config, warmup []backtester.ChartPoint) {
+	s.symbol = config.Symbol
+}
+
+// Score evaluates the model on the current feature values
+func (s *ModelStrategy) Score() float64 {
+	score := s.bias
+	for _, term := range s.terms {
+		score += term.weight * (s.values[term.name] - term.mean) / term.std
+	}
+	if s.logistic {
+		score = 1 / (1 + math.Exp(-score))
+	}
+	return score
+}
+
+// nextTarget applies the thresholds to the score given the current target
+func (s *ModelStrategy) nextTarget() float64 {
+	t, target := s.thresholds, s.target
+	if target > 0 && s.score < t.ExitLong || target < 0 && s.score > t.ExitShort {
+		target = 0
+	}
+	if target == 0 {
+		if s.score >= t.EnterLong {
+			return 1
+		} else if s.score <= t.EnterShort {
+			return -1
+		}
+	}
+	return target
+}
+
+// OnTick implements backtester.Strategy
+func (s *ModelStrategy) OnTick(point backtester.ChartPoint) backtester.Signal {
+	signal := backtester.Signal{Action: "HOLD", Price: point.Price, Time: point.Timestamp()}
+	if s.symbol != "" && point.Symbol != s.symbol {
+		return signal
+	}
+
+	s.set.Update(point.Price, point.Qty)
+	if !s.set.Ready() {
+		return signal
+	}
+	s.set.Values(s.values)
+	s.values["price"], s.values["qty"] = point.Price, point.Qty
+	s.score = s.Score()
+
+	target := s.nextTarget()
+	if target == s.target && !s.resync {
+		return signal
+	}
+	s.target = target
+	s.resync = false
+	signal.Action = "TARGET"
+	signal.Target = target
+	signal.Reason = fmt.Sprintf("model score %.4g", s.score)
+	signal.Values = map[string]float64{"score": s.score}
+	for _, term := range s.terms {
+		signal.Values[term.name] = s.values[term.name]
+	}
+	return signal
+}
+
+// OnReject implements backtester.RejectListener
+func (s *ModelStrategy) OnReject(order *backtester.Order, err error) {
+	s.resync = true
+}
+
+// Series implements backtester.SeriesPublisher
+func (s *ModelStrategy) Series() map[string]float64 {
+	if !s.set.Ready() {
+		return nil
+	}
+	return map[string]float64{"score": s.score, "target": s.target}
+}
@@ -0,0 +1,114 @@
+package strategies
+
+import (
+	"hft-backtester/backtester"
+	"hft-backtester/indicators"
+)
+
+func init() {
+	Register("orderflow", func(params map[string]interface{}) (backtester.Strategy, error) {
+		return NewOrderFlowStrategy(
+			intParam(params, "window", 200),
+			intParam(params, "trendWindow", 1000),
+			floatParam(params, "entryThreshold", 0.3),
+			floatParam(params, "exitThreshold", 0.0),
+		), nil
+	})
+}
+
+// OrderFlowStrategy trades on the imbalance between aggressive buy and sell volume.
+//
+// Imbalance is (buy - sell) / (buy + sell) over the last window trades. It enters
+// long above entryThreshold and short below -entryThreshold, provided the volume
+// delta over the trend window agrees, and exits once imbalance crosses back past
+// exitThreshold.
+type OrderFlowStrategy struct {
+	buyVolume       *indicators.Sum
+	sellVolume      *indicators.Sum
+	trendDelta      *indicators.Sum // nil when the trend filter is disabled
+	cumulativeDelta float64
+	entryThreshold  float64
+	exitThreshold   float64
+}
+
+// NewOrderFlowStrategy creates a new order-flow imbalance strategy.
+// A trendWindow of zero disables the trend filter.
+func NewOrderFlowStrategy(window, trendWindow int, entryThreshold, exitThreshold float64) *OrderFlowStrategy {
+	s := &OrderFlowStrategy{
+		buyVolume:      indicators.NewSum(window),
+		sellVolume:     indicators.NewSum(window),
+		entryThreshold: entryThreshold,
+		exitThreshold:  exitThreshold,
+	}
+	if trendWindow > 0 {
+		s.trendDelta = indicators.NewSum(trendWindow)
+	}
+	return s
+}
+
+// Update records the aggressor volume of a trade
+func (s *OrderFlowStrategy) Update(point backtester.ChartPoint) {
+	buy, sell := point.Qty, 0.0
+	if point.IsBuyerMaker {
+		// Buyer was the resting side, so the seller was the aggressor
+		buy, sell = 0, point.Qty
+	}
+
+	s.buyVolume.Update(buy)
+	s.sellVolume.Update(sell)
+	s.cumulativeDelta += buy - sell
+	if s.trendDelta != nil {
+		s.trendDelta.Update(buy - sell)
+	}
+}
+
+// Imbalance returns the aggressor volume imbalance in [-1, 1]
+func (s *OrderFlowStrategy) Imbalance() float64 {
+	total := s.buyVolume.Value() + s.sellVolume.Value()
+	if total <= 0 {
+		return 0
+	}
+	return (s.buyVolume.Value() - s.sellVolume.Value()) / total
+}
+
+// trend reports whether the volume delta over the trend window agrees with direction
+func (s *OrderFlowStrategy) trend(direction float64) bool {
+	if s.trendDelta == nil {
+		return true
+	}
+	return s.trendDelta.Ready() && s.trendDelta.Value()*direction > 0
+}
+
+// OnTick implements backtester.Strategy
+func (s *OrderFlowStrategy) OnTick(point backtester.ChartPoint) backtester.Signal {
+	s.Update(point)
+	signal := backtester.Signal{Action: "HOLD", Price: point.Price, Time: point.Timestamp()}
+	if !s.buyVolume.Ready() {
+		return signal
+	}
+
+	imbalance := s.Imbalance()
+	switch {
+	case imbalance > s.entryThreshold && s.trend(1):
+		signal.Action = "BUY"
+	case imbalance < -s.entryThreshold && s.trend(-1):
+		signal.Action = "SELL"
+	case imbalance < s.exitThreshold:
+		signal.Action = "EXIT_LONG"
+	case imbalance > -s.exitThreshold:
+		signal.Action = "EXIT_SHORT"
+	}
+	if signal.Action != "HOLD" {
+		signal.Reason = "volume imbalance"
+		signal.Values = s.Series()
+	}
+	return signal
+}
+
+// Series implements backtester.SeriesPublisher
+func (s *OrderFlowStrategy) Series() map[string]float64 {
+	return map[string]float64{
+		"imbalance":        s.Imbalance(),
+		"cumulative_delta": s.cumulativeDelta,
+	}
+}
@@ -0,0 +1,207 @@
+package strategies
+
+import (
+	"errors"
+	"fmt"
+	"hft-backtester/backtester"
+	"hft-backtester/indicators"
+	"math"
+)
+
+// errPairsSymbols rejects a pairs strategy with neither symbols set nor two symbols replayed
+var errPairsSymbols = errors.New("pairs strategy needs two symbols: replay at least two or set symbolY and symbolX")
+
+func init() {
+	Register("pairs", func(params map[string]interface{}) (backtester.Strategy, error) {
+		symbolY := stringParam(params, "symbolY", "")
+		symbolX := stringParam(params, "symbolX", "")
+		if (symbolY == "") != (symbolX == "") || (symbolY != "" && symbolY == symbolX) {
+			return nil, fmt.Errorf("pairs strategy needs two different symbols in symbolY and symbolX, or neither to use the first two replayed symbols")
+		}
+		return NewPairsStrategy(
+			symbolY,
+			symbolX,
+			intParam(params, "window", 200),
+			floatParam(params, "entryZ", 2),
+			floatParam(params, "exitZ", 0.5),
+		), nil
+	})
+}
+
+// PairsStrategy trades the spread between two symbols.
+//
+// The hedge ratio is a rolling OLS fit of Y on X. When the z-score of the
+// residual spread exceeds entryZ, it sells Y and buys Beta units of X per unit
+// of Y (or the reverse below -entryZ), and closes both legs once |z| < exitZ.
+type PairsStrategy struct {
+	symbolY      string
+	symbolX      string
+	hedge        *indicators.RollingRegression
+	spread       *indicators.ZScore
+	entryZ       float64
+	exitZ        float64
+	positionSize float64
+	prices       map[string]float64
+	positions    map[string]float64 // Filled quantity per leg
+	state        int                // 1 long spread, -1 short spread, 0 flat
+	broken       bool               // A leg was rejected; flatten on the next tick
+	err          error              // Set by OnStart when there is no pair to trade
+}
+
+// NewPairsStrategy creates a new pairs strategy. Empty symbols default to
+// the first two replayed symbols.
+func NewPairsStrategy(symbolY, symbolX string, window int, entryZ, exitZ float64) *PairsStrategy {
+	return &PairsStrategy{
+		symbolY:   symbolY,
+		symbolX:   symbolX,
+		hedge:     indicators.NewRollingRegression(window),
+		spread:    indicators.NewZScore(window),
+		entryZ:    entryZ,
+		exitZ:     exitZ,
+		prices:    make(map[string]float64),
+		positions: make(map[string]float64),
+	}
+}
+
+// OnStart implements backtester.Starter
+func (p *PairsStrategy) OnStart(config backtester.Config, warmup []backtester.ChartPoint) {
+	p.positionSize = config.PositionSize
+	if p.symbolY == "" && p.symbolX == "" {
+		if len(config.Symbols) < 2 {
+			p.err = errPairsSymbols
+			return
+		}
+		p.symbolY, p.symbolX = config.Symbols[0], config.Symbols[1]
+	}
+}
+
+// CheckSymbols fails, stopping the strategy, if it is or contains a pairs strategy without
+// symbols set while fewer than two symbols are replayed, so the request is rejected before
+// the data is replayed rather than by OnStart
+func CheckSymbols(strategy backtester.Strategy, symbols []string) error {
+	if err := missingSymbols(strategy, symbols); err != nil {
+		closeStrategy(strategy)
+		return err
+	}
+	return nil
+}
+
+// missingSymbols implements CheckSymbols for one strategy and its children
+func missingSymbols(strategy backtester.Strategy, symbols []string) error {
+	switch s := strategy.(type) {
+	case *PairsStrategy:
+		if s.symbolY == "" && len(symbols) < 2 {
+			return errPairsSymbols
+		}
+	case *CompositeStrategy:
+		for _, child := range s.children {
+			if err := missingSymbols(child.strategy, symbols); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Err returns the configuration error found by OnStart, if any
+func (p *PairsStrategy) Err() error {
+	return p.err
+}
+
+// OnTick implements backtester.Strategy
+func (p *PairsStrategy) OnTick(point backtester.ChartPoint) backtester.Signal {
+	signal := backtester.Signal{Action: "HOLD", Price: point.Price, Time: point.Timestamp()}
+	if point.Symbol != p.symbolY && point.Symbol != p.symbolX {
+		return signal
+	}
+	p.prices[point.Symbol] = point.Price
+
+	if p.broken {
+		p.broken = false
+		p.state = 0
+		signal.Orders = p.flatten()
+		signal.Reason = "leg rejected, flattening"
+		return signal
+	}
+
+	y, okY := p.prices[p.symbolY]
+	x, okX := p.prices[p.symbolX]
+	if !okY || !okX {
+		return signal
+	}
+
+	p.hedge.Update(x, y)
+	if !p.hedge.Ready() {
+		return signal
+	}
+	p.spread.Update(y - p.hedge.Alpha() - p.hedge.Beta()*x)
+	if !p.spread.Ready() {
+		return signal
+	}
+
+	z := p.spread.Value()
+	beta := p.hedge.Beta()
+	switch {
+	case p.state == 0 && beta > 0 && z > p.entryZ:
+		p.state = -1
+		signal.Orders = p.enter(y, beta, false)
+		signal.Reason = "spread z-score above entryZ"
+	case p.state == 0 && beta > 0 && z < -p.entryZ:
+		p.state = 1
+		signal.Orders = p.enter(y, beta, true)
+		signal.Reason = "spread z-score below -entryZ"
+	case p.state != 0 && math.Abs(z) < p.exitZ:
+		p.state = 0
+		signal.Orders = p.flatten()
+		signal.Reason = "spread z-score back within exitZ"
+	default:
+		return signal
+	}
+	signal.Values = map[string]float64{"z": z, "hedge_ratio": beta, "price_y": y, "price_x": x}
+	return signal
+}
+
+// enter creates both legs of a spread position worth positionSize on the Y leg
+func (p *PairsStrategy) enter(priceY, beta float64, longSpread bool) []*backtester.Order {
+	qtyY := p.positionSize / priceY
+	return []*backtester.Order{
+		{Symbol: p.symbolY, Qty: qtyY, IsBuy: longSpread},
+		{Symbol: p.symbolX, Qty: qtyY * beta, IsBuy: !longSpread},
+	}
+}
+
+// flatten creates orders closing both legs
+func (p *PairsStrategy) flatten() []*backtester.Order {
+	var orders []*backtester.Order
+	for _, symbol := range []string{p.symbolY, p.symbolX} {
+		if qty := p.positions[symbol]; qty != 0 {
+			orders = append(orders, &backtester.Order{Symbol: symbol, Qty: math.Abs(qty), IsBuy: qty < 0})
+		}
+	}
+	return orders
+}
+
+// OnFill implements backtester.FillListener
+func (p *PairsStrategy) OnFill(trade *backtester.Trade) {
+	if trade.IsBuy {
+		p.positions[trade.Symbol] += trade.Qty
+	} else {
+		p.positions[trade.Symbol] -= trade.Qty
+	}
+}
+
+// OnReject implements backtester.RejectListener
+func (p *PairsStrategy) OnReject(order *backtester.Order, err error) {
+	p.broken = true
+}
+
+// Series implements backtester.SeriesPublisher
+func (p *PairsStrategy) Series() map[string]float64 {
+	if !p.spread.Ready() {
+		return nil
+	}
+	return map[string]float64{
+		"spread_z":    p.spread.Value(),
+		"hedge_ratio": p.hedge.Beta(),
+	}
+}
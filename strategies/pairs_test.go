@@ -0,0 +1,36 @@
+package strategies
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckSymbols(t *testing.T) {
+	pairs := map[string]interface{}{"strategy": "pairs"}
+	tests := []struct {
+		name     string
+		strategy string
+		params   map[string]interface{}
+		symbols  []string
+		wantErr  bool
+	}{
+		{"pairs on two replayed symbols", "pairs", nil, []string{"BTC", "ETH"}, false},
+		{"pairs on one replayed symbol", "pairs", nil, []string{"BTC"}, true},
+		{"pairs with symbols set", "pairs", map[string]interface{}{"symbolY": "BTC", "symbolX": "ETH"}, []string{"BTC"}, false},
+		{"pairs inside a composite", "composite", map[string]interface{}{"children": []interface{}{pairs}}, []string{"BTC"}, true},
+		{"other strategies", "bollinger", nil, []string{"BTC"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategy, err := New(tt.strategy, tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = CheckSymbols(strategy, tt.symbols)
+			if (err != nil) != tt.wantErr || (err != nil && !errors.Is(err, errPairsSymbols)) {
+				t.Fatalf("got %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package strategies
+
+import (
+	"fmt"
+	"hft-backtester/backtester"
+)
+
+// Factory creates a strategy from request parameters
+type Factory func(params map[string]interface{}) (backtester.Strategy, error)
+
+var registry = make(map[string]Factory)
+
+// Register makes a strategy available by name
+func Register(name string, factory Factory) {
+	registry[name] = factory
+}
+
+// New creates a registered strategy by name
+func New(name string, params map[string]interface{}) (backtester.Strategy, error) {
+	factory, exists := registry[name]
+	if !exists {
+		return nil, fmt.Errorf("unknown strategy %q", name)
+	}
+	return factory(params)
+}
+
+// floatParam reads a numeric parameter, falling back to def
+func floatParam(params map[string]interface{}, key string, def float64) float64 {
+	if v, ok := params[key].(float64); ok {
+		return v
+	}
+	return def
+}
+
+// intParam reads an integer parameter, falling back to def
+func intParam(params map[string]interface{}, key string, def int) int {
+	if v, ok := params[key].(float64); ok {
+		return int(v)
+	}
+	return def
+}
+
+// stringParam reads a string parameter, falling back to def
+func stringParam(params map[string]interface{}, key string, def string) string {
+	if v, ok := params[key].(string); ok {
+		return v
+	}
+	return def
+}
+
+// stringsParam reads a list of strings parameter
+func stringsParam(params map[string]interface{}, key string) []string {
+	list, _ := params[key].([]interface{})
+	values := make([]string, 0, len(list))
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values
+}
@@ -0,0 +1,138 @@
+package strategies
+
+import (
+	"fmt"
+	"hft-backtester/backtester"
+	"hft-backtester/indicators"
+)
+
+func init() {
+	Register("rules", func(params map[string]interface{}) (backtester.Strategy, error) {
+		specs, _ := params["indicators"].(map[string]interface{})
+		set, err := indicators.NewSet(specs)
+		if err != nil {
+			return nil, err
+		}
+		return NewRulesStrategy(set, map[string]string{
+			"BUY":        stringParam(params, "entryLong", ""),
+			"SELL":       stringParam(params, "entryShort", ""),
+			"EXIT_LONG":  stringParam(params, "exitLong", ""),
+			"EXIT_SHORT": stringParam(params, "exitShort", ""),
+		})
+	})
+}
+
+// ruleActions lists signal actions in the order their rules are checked
+var ruleActions = []string{"BUY", "SELL", "EXIT_LONG", "EXIT_SHORT"}
+
+// RulesStrategy trades on conditions written as expressions over a set of indicators.
+//
+// Expressions may refer to price, qty, position (net quantity held) and the values of
+// the indicator set, e.g. `price < bb.lower && rsi < 30`. Rules are evaluated once all
+// indicators are ready; the first true rule in the order entryLong, entryShort,
+// exitLong, exitShort decides the signal.
+type RulesStrategy struct {
+	set      *indicators.Set
+	rules    map[string]expr
+	sources  map[string]string
+	slots    map[string]int
+	vars     []float64
+	values   map[string]float64
+	symbol   string // Only trades of this symbol are evaluated; empty accepts all
+	position float64
+}
+
+// NewRulesStrategy compiles the rule expressions keyed by signal action.
+// Empty expressions are skipped.
+func NewRulesStrategy(set *indicators.Set, rules map[string]string) (*RulesStrategy, error) {
+	s := &RulesStrategy{
+		set:     set,
+		rules:   make(map[string]expr),
+		sources: make(map[string]string),
+		slots:   map[string]int{"price": 0, "qty": 1, "position": 2},
+		values:  make(map[string]float64),
+	}
+	for _, name := range set.Names() {
+		if _, taken := s.slots[name]; taken {
+			return nil, fmt.Errorf("indicator %q shadows a built-in variable", name)
+		}
+		s.slots[name] = len(s.slots)
+	}
+	s.vars = make([]float64, len(s.slots))
+
+	for action, source := range rules {
+		if source == "" {
+			continue
+		}
+		compiled, err := compileExpr(source, s.slots)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", action, err)
+		}
+		s.rules[action] = compiled
+		s.sources[action] = source
+	}
+	if len(s.rules) == 0 {
+		return nil, fmt.Errorf("rules strategy needs at least one rule")
+	}
+	return s, nil
+}
+
+// OnStart implements backtester.Starter
+func (s *RulesStrategy) OnStart(config backtester.Config, warmup []backtester.ChartPoint) {
+	s.symbol = config.Symbol
+}
+
+// OnTick implements backtester.Strategy
+func (s *RulesStrategy) OnTick(point backtester.ChartPoint) backtester.Signal {
+	signal := backtester.Signal{Action: "HOLD", Price: point.Price, Time: point.Timestamp()}
+	if s.symbol != "" && point.Symbol != s.symbol {
+		return signal
+	}
+
+	s.set.Update(point.Price, point.Qty)
+	if !s.set.Ready() {
+		return signal
+	}
+
+	s.set.Values(s.values)
+	for name, value := range s.values {
+		s.vars[s.slots[name]] = value
+	}
+	s.vars[0], s.vars[1], s.vars[2] = point.Price, point.Qty, s.position
+
+	for _, action := range ruleActions {
+		if rule, ok := s.rules[action]; ok && rule(s.vars) != 0 {
+			signal.Action = action
+			signal.Reason = s.sources[action]
+			signal.Values = s.snapshot()
+			break
+		}
+	}
+	return signal
+}
+
+// snapshot copies the current variable values
+func (s *RulesStrategy) snapshot() map[string]float64 {
+	values := make(map[string]float64, len(s.slots))
+	for name, slot := range s.slots {
+		values[name] = s.vars[slot]
+	}
+	return values
+}
+
+// OnFill implements backtester.FillListener
+func (s *RulesStrategy) OnFill(trade *backtester.Trade) {
+	if trade.IsBuy {
+		s.position += trade.Qty
+	} else {
+		s.position -= trade.Qty
+	}
+}
+
+// Series implements backtester.SeriesPublisher
+func (s *RulesStrategy) Series() map[string]float64 {
+	if !s.set.Ready() {
+		return nil
+	}
+	return s.values
+}
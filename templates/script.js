@@ -1,4 +1,5 @@
 let equityPlot = null;
+let pricePlot = null;
 let uplot = null; // Объявляем переменную uplot
 
 // Load available hours
@@ -22,37 +23,109 @@ fetch('/api/hours')
         document.getElementById('status').textContent = 'Error loading hours: ' + err.message;
     });
 
+// Parameter inputs per strategy: [param name, label, default value, step]
+const strategyParamFields = {
+    bollinger: [
+        ['period', 'Period', 100, 1],
+        ['stdDev', 'Standard Deviations', 1, 0.1]
+    ],
+    orderflow: [
+        ['window', 'Imbalance Window (trades)', 200, 10],
+        ['trendWindow', 'Trend Window (trades)', 1000, 100],
+        ['entryThreshold', 'Entry Imbalance', 0.3, 0.05],
+        ['exitThreshold', 'Exit Imbalance', 0, 0.05]
+    ],
+    marketmaker: [
+        ['fairWindow', 'Fair Price EMA (trades)', 50, 10],
+        ['spreadBps', 'Spread (bps)', 10, 1],
+        ['size', 'Quote Size ($)', 10, 1],
+        ['maxInventory', 'Max Inventory ($)', 50, 10],
+        ['skew', 'Inventory Skew', 1, 0.1]
+    ],
+    pairs: [
+        ['window', 'Hedge Window (trades)', 200, 10],
+        ['entryZ', 'Entry Z-Score', 2, 0.1],
+        ['exitZ', 'Exit Z-Score', 0.5, 0.1]
+    ]
+};
+
 function updateStrategyParams() {
     const strategy = document.getElementById('strategySelect').value;
     const paramsDiv = document.getElementById('strategyParams');
     
-    if (strategy === 'bollinger') {
-        paramsDiv.innerHTML = '<label for="bbPeriod">Period:</label><input type="number" id="bbPeriod" value="100" min="2"><label for="bbStdDev">Standard Deviations:</label><input type="number" id="bbStdDev" value="1" step="0.1" min="0.1">';
-    }
+    paramsDiv.innerHTML = (strategyParamFields[strategy] || []).map(([name, label, value, step]) =>
+        '<label for="param_' + name + '">' + label + ':</label><input type="number" id="param_' + name + '" value="' + value + '" step="' + step + '">'
+    ).join('');
 }
 
-function runBacktest() {
-    document.getElementById('status').textContent = 'Running backtest...';
-    
+updateStrategyParams();
+
+function buildRequest() {
     const strategy = document.getElementById('strategySelect').value;
     const initialCash = parseFloat(document.getElementById('initialCash').value);
     const positionSize = parseFloat(document.getElementById('positionSize').value);
     const commission = parseFloat(document.getElementById('commission').value);
     const strategyParams = {};
     
-    if (strategy === 'bollinger') {
-        strategyParams.period = parseInt(document.getElementById('bbPeriod').value);
-        strategyParams.stdDev = parseFloat(document.getElementById('bbStdDev').value);
-    }
+    (strategyParamFields[strategy] || []).forEach(([name]) => {
+        strategyParams[name] = parseFloat(document.getElementById('param_' + name).value);
+    });
+    
+    const symbols = document.getElementById('symbols').value.split(',').map(symbol => symbol.trim().toUpperCase()).filter(symbol => symbol);
     
-    const requestData = {
+    return {
         strategy: strategy,
+        symbols: symbols,
         initial_cash: initialCash,
         position_size: positionSize,
         commission: commission,
         hour: document.getElementById('hourSelect').value,
+        warmup: parseInt(document.getElementById('warmup').value) || 0,
+        queue_model: document.getElementById('queueModel').value,
+        queue_ahead: parseFloat(document.getElementById('queueAhead').value) || 0,
+        slippage: buildSlippage(),
+        fees: buildFees(commission),
+        impact: {
+            temporary: parseFloat(document.getElementById('impactTemporary').value) || 0,
+            permanent: parseFloat(document.getElementById('impactPermanent').value) || 0,
+            half_life_ms: Math.round((parseFloat(document.getElementById('impactHalfLife').value) || 0) * 1000)
+        },
+        latency: {
+            order_ms: parseFloat(document.getElementById('orderLatency').value) || 0,
+            report_ms: parseFloat(document.getElementById('reportLatency').value) || 0
+        },
+        participation: parseFloat(document.getElementById('participation').value) || 0,
+        exchange_info: document.getElementById('exchangeInfo').value.trim(),
+        filter_mode: document.getElementById('filterMode').value,
         strategy_params: strategyParams
     };
+}
+
+function buildFees(commission) {
+    const maker = document.getElementById('makerFee').value;
+    const discount = parseFloat(document.getElementById('feeDiscount').value) || 0;
+    if (maker === '' && discount === 0) {
+        return {};
+    }
+    return {
+        tiers: [{ volume: 0, maker: maker === '' ? commission : parseFloat(maker), taker: commission }],
+        discount: discount
+    };
+}
+
+function buildSlippage() {
+    const model = document.getElementById('slippageModel').value;
+    const value = parseFloat(document.getElementById('slippageValue').value) || 0;
+    if (model === 'volatility' || model === 'participation') {
+        return { model: model, factor: value };
+    }
+    return { model: model, bps: value };
+}
+
+function runBacktest() {
+    document.getElementById('status').textContent = 'Running backtest...';
+    
+    const requestData = buildRequest();
     
     fetch('/api/backtest', {
         method: 'POST',
@@ -71,6 +144,41 @@ function runBacktest() {
     });
 }
 
+function downloadDecisionLog() {
+    document.getElementById('status').textContent = 'Building decision log...';
+    
+    fetch('/api/backtest/decisions', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json'
+        },
+        body: JSON.stringify(buildRequest())
+    })
+    .then(response => {
+        if (!response.ok) {
+            return response.json().then(data => { throw new Error(data.error); });
+        }
+        return response.blob();
+    })
+    .then(blob => {
+        const link = document.createElement('a');
+        link.href = URL.createObjectURL(blob);
+        link.download = 'decisions.ndjson';
+        link.click();
+        URL.revokeObjectURL(link.href);
+        document.getElementById('status').textContent = 'Decision log downloaded';
+    })
+    .catch(err => {
+        document.getElementById('status').textContent = 'Error building decision log: ' + err.message;
+    });
+}
+
+function escapeHtml(text) {
+    const div = document.createElement('div');
+    div.textContent = text || '';
+    return div.innerHTML;
+}
+
 function displayResults(data) {
     // Display metrics
     const metricsDiv = document.getElementById('metrics');
@@ -96,7 +204,25 @@ function displayResults(data) {
             <div class="metric-value">${profitPct.toFixed(2)}%</div>
             <div class="metric-label">Profit Percentage</div>
         </div>
-    `;
+    ` + [['commission', 'Commission'], ['slippage', 'Slippage Cost'], ['impact', 'Impact Cost']].map(([key, label]) => `
+        <div class="metric-card">
+            <div class="metric-value">$${((data.costs || {})[key] || 0).toFixed(4)}</div>
+            <div class="metric-label">${label}</div>
+        </div>
+    `).join('') + Object.keys(data.symbol_pnl || {}).sort().map(symbol => `
+        <div class="metric-card">
+            <div class="metric-value">$${data.symbol_pnl[symbol].toFixed(2)}</div>
+            <div class="metric-label">${symbol} PnL</div>
+        </div>
+    `).join('') + Object.keys(data.rejections || {}).sort().map(reason => `
+        <div class="metric-card">
+            <div class="metric-value">${data.rejections[reason]}</div>
+            <div class="metric-label">Rejected: ${escapeHtml(reason)}</div>
+        </div>
+    `).join('');
+    
+    // Display price chart with indicator overlays and trades
+    displayPriceChart(data);
     
     // Display equity curve
     if (data.equity_curve && data.equity_curve.length > 0) {
@@ -160,13 +286,14 @@ function displayResults(data) {
     // Display trades table
     if (data.trades && data.trades.length > 0) {
         const tableDiv = document.getElementById('tradesTable');
-        let tableHTML = '<h3>Trade History</h3><table><thead><tr><th>Entry Time</th><th>Entry Price</th><th>Exit Time</th><th>Exit Price</th><th>Side</th><th>Quantity</th><th>Commission</th><th>Profit/Loss</th></tr></thead><tbody>';
+        let tableHTML = '<h3>Trade History</h3><table><thead><tr><th>Entry Time</th><th>Entry Price</th><th>Exit Time</th><th>Exit Price</th><th>Side</th><th>Quantity</th><th>Commission</th><th>Profit/Loss</th><th>Entry Reason</th><th>Exit Reason</th></tr></thead><tbody>';
         
-        // Group trades into entries and exits
-        for (let i = 0; i < data.trades.length; i += 2) {
-            if (i + 1 < data.trades.length) {
-                const entryTrade = data.trades[i];
-                const exitTrade = data.trades[i + 1];
+        // Merge the partial fills of each order, then group orders into entries and exits
+        const orders = groupFillsByOrder(data.trades);
+        for (let i = 0; i < orders.length; i += 2) {
+            if (i + 1 < orders.length) {
+                const entryTrade = orders[i];
+                const exitTrade = orders[i + 1];
                 
                 const entryTime = new Date(entryTrade.time).toLocaleString();
                 const exitTime = new Date(exitTrade.time).toLocaleString();
@@ -183,7 +310,7 @@ function displayResults(data) {
                     profitLoss = (entryTrade.price - exitTrade.price) * entryTrade.qty - commission;
                 }
                 
-                tableHTML += '<tr><td>' + entryTime + '</td><td>$' + entryTrade.price.toFixed(2) + '</td><td>' + exitTime + '</td><td>$' + exitTrade.price.toFixed(2) + '</td><td>' + (entryTrade.is_buy ? 'LONG' : 'SHORT') + '</td><td>' + entryTrade.qty.toFixed(4) + '</td><td>$' + commission.toFixed(4) + '</td><td style="' + (profitLoss >= 0 ? 'color: green;' : 'color: red;') + '">$' + profitLoss.toFixed(2) + '</td></tr>';
+                tableHTML += '<tr><td>' + entryTime + '</td><td>$' + entryTrade.price.toFixed(2) + '</td><td>' + exitTime + '</td><td>$' + exitTrade.price.toFixed(2) + '</td><td>' + (entryTrade.is_buy ? 'LONG' : 'SHORT') + '</td><td>' + entryTrade.qty.toFixed(4) + '</td><td>$' + commission.toFixed(4) + '</td><td style="' + (profitLoss >= 0 ? 'color: green;' : 'color: red;') + '">$' + profitLoss.toFixed(2) + '</td><td>' + escapeHtml(entryTrade.reason) + '</td><td>' + escapeHtml(exitTrade.reason) + '</td></tr>';
             }
         }
         
@@ -193,8 +320,134 @@ function displayResults(data) {
     }
 }
 
+// Merge trades sharing an order_id into one at their volume-weighted price and first fill time
+function groupFillsByOrder(trades) {
+    const orders = [];
+    const byOrder = {};
+    trades.forEach(trade => {
+        const key = trade.order_id || trade.id;
+        const order = byOrder[key];
+        if (!order) {
+            byOrder[key] = Object.assign({}, trade);
+            orders.push(byOrder[key]);
+            return;
+        }
+        const qty = order.qty + trade.qty;
+        order.price = (order.price * order.qty + trade.price * trade.qty) / qty;
+        order.qty = qty;
+        order.commission += trade.commission;
+    });
+    return orders;
+}
+
+function displayPriceChart(data) {
+    if (!data.price_data || data.price_data.length === 0) return;
+    
+    if (pricePlot) {
+        pricePlot.destroy();
+    }
+    
+    // Only the primary symbol is drawn when several were replayed
+    const primary = data.price_data[0].symbol;
+    const priceData = data.price_data.filter(point => point.symbol === primary);
+    
+    const timestamps = priceData.map(point => point.time / 1000);
+    const plotData = [timestamps, priceData.map(point => point.price)];
+    const series = [
+        {},
+        {
+            label: "Price",
+            stroke: "blue",
+            width: 1,
+            points: { show: false }
+        }
+    ];
+    
+    // Align a list of {time, value} points to the price timestamps
+    const align = (points, valueOf) => {
+        const byTime = new Map(points.map(point => [point.time, valueOf(point)]));
+        return priceData.map(point => byTime.has(point.time) ? byTime.get(point.time) : null);
+    };
+    
+    // Series far outside the price range (e.g. oscillators) are drawn on a secondary axis
+    const prices = plotData[1];
+    const minPrice = Math.min(...prices);
+    const maxPrice = Math.max(...prices);
+    const isOverlay = points => points.every(point => point.value >= minPrice * 0.5 && point.value <= maxPrice * 1.5);
+    let hasIndicatorScale = false;
+    
+    const overlayColors = ["orange", "purple", "teal", "brown", "magenta"];
+    Object.keys(data.series || {}).sort().forEach((name, i) => {
+        const overlay = isOverlay(data.series[name]);
+        hasIndicatorScale = hasIndicatorScale || !overlay;
+        plotData.push(align(data.series[name], point => point.value));
+        series.push({
+            label: name,
+            scale: overlay ? "y" : "ind",
+            stroke: overlayColors[i % overlayColors.length],
+            width: 1,
+            spanGaps: true,
+            points: { show: false }
+        });
+    });
+    
+    const trades = (data.trades || []).filter(trade => !primary || trade.symbol === primary).map(trade => ({ time: new Date(trade.time).getTime(), price: trade.price, is_buy: trade.is_buy }));
+    [["Buys", true, "green"], ["Sells", false, "red"]].forEach(([label, isBuy, color]) => {
+        plotData.push(align(trades.filter(trade => trade.is_buy === isBuy), trade => trade.price));
+        series.push({
+            label: label,
+            stroke: color,
+            width: 0,
+            points: { show: true, size: 7, fill: color }
+        });
+    });
+    
+    const opts = {
+        title: "Price",
+        id: "price-chart",
+        class: "my-chart",
+        width: document.getElementById('priceChart').offsetWidth,
+        height: 500,
+        series: series,
+        axes: [
+            {
+                label: "Time",
+                labelSize: 80,
+                stroke: "black",
+                grid: { show: true, stroke: "#eee" },
+                ticks: { show: true, stroke: "#ddd" },
+                scale: "x"
+            },
+            {
+                label: "Price ($)",
+                labelSize: 60,
+                stroke: "black",
+                grid: { show: true, stroke: "#eee" },
+                ticks: { show: true, stroke: "#ddd" },
+                scale: "y"
+            }
+        ].concat(hasIndicatorScale ? [{
+            label: "Indicator",
+            side: 1,
+            stroke: "gray",
+            grid: { show: false },
+            scale: "ind"
+        }] : []),
+        cursor: {
+            show: true,
+            drag: { show: true, x: true, y: false, setScale: true }
+        },
+        legend: { show: true }
+    };
+    
+    pricePlot = new uPlot(opts, plotData, document.getElementById('priceChart'));
+}
+
 // Handle window resize
 window.addEventListener('resize', () => {
+    if (pricePlot) {
+        pricePlot.setSize(document.getElementById('priceChart').offsetWidth, 500);
+    }
     if (equityPlot) {
         equityPlot.setSize(document.getElementById('equityChart').offsetWidth, 500);
     }
@@ -21,10 +21,18 @@ func GetHTMLTemplate() string {
                 </select>
             </div>
             
+            <div class="form-group">
+                <label for="symbols">Symbols:</label>
+                <input type="text" id="symbols" placeholder="STBLUSDT (comma separated)">
+            </div>
+            
             <div class="form-group">
                 <label for="strategySelect">Strategy:</label>
                 <select id="strategySelect" onchange="updateStrategyParams()">
                     <option value="bollinger">Bollinger Bands</option>
+                    <option value="orderflow">Order-Flow Imbalance</option>
+                    <option value="marketmaker">Market Making</option>
+                    <option value="pairs">Pairs (first two symbols)</option>
                 </select>
             </div>
             
@@ -43,17 +51,101 @@ func GetHTMLTemplate() string {
                 <input type="number" id="commission" value="0.05" step="0.01" min="0">
             </div>
             
+            <div class="form-group">
+                <label for="makerFee">Maker Fee (%, blank = commission, negative = rebate):</label>
+                <input type="number" id="makerFee" step="0.01">
+            </div>
+            
+            <div class="form-group">
+                <label for="feeDiscount">Fee Asset Discount (%):</label>
+                <input type="number" id="feeDiscount" value="0" step="5" min="0" max="100">
+            </div>
+            
+            <div class="form-group">
+                <label for="warmup">Warm-up (trades):</label>
+                <input type="number" id="warmup" value="0" step="100" min="0">
+            </div>
+            
+            <div class="form-group">
+                <label for="queueModel">Limit Fill Queue:</label>
+                <select id="queueModel">
+                    <option value="">Fill on touch</option>
+                    <option value="conservative">Conservative</option>
+                    <option value="optimistic">Optimistic</option>
+                </select>
+            </div>
+            
+            <div class="form-group">
+                <label for="queueAhead">Queue Ahead (qty):</label>
+                <input type="number" id="queueAhead" value="0" step="100" min="0">
+            </div>
+            
+            <div class="form-group">
+                <label for="slippageModel">Slippage Model:</label>
+                <select id="slippageModel">
+                    <option value="">None</option>
+                    <option value="fixed">Fixed (bps)</option>
+                    <option value="spread">Half spread (bps, 0 = estimate)</option>
+                    <option value="volatility">Volatility (std dev multiple)</option>
+                    <option value="participation">Participation (bps at 100% of volume)</option>
+                </select>
+            </div>
+            
+            <div class="form-group">
+                <label for="slippageValue">Slippage Parameter:</label>
+                <input type="number" id="slippageValue" value="0" step="0.5" min="0">
+            </div>
+            
+            <div class="form-group">
+                <label for="impactTemporary">Temporary Impact (coef, sqrt law):</label>
+                <input type="number" id="impactTemporary" value="0" step="0.1" min="0">
+            </div>
+            
+            <div class="form-group">
+                <label for="impactPermanent">Permanent Impact (coef, linear):</label>
+                <input type="number" id="impactPermanent" value="0" step="0.1" min="0">
+            </div>
+            
+            <div class="form-group">
+                <label for="impactHalfLife">Permanent Impact Half-Life (s):</label>
+                <input type="number" id="impactHalfLife" value="60" step="1" min="1">
+            </div>
+            
+            <div class="form-group">
+                <label for="exchangeInfo">Exchange Info File (symbol filters):</label>
+                <input type="text" id="exchangeInfo" placeholder="exchangeInfo.json">
+            </div>
+            
+            <div class="form-group">
+                <label for="filterMode">Orders Off Tick/Step:</label>
+                <select id="filterMode">
+                    <option value="">Round</option>
+                    <option value="reject">Reject</option>
+                </select>
+            </div>
+            
+            <div class="form-group">
+                <label for="orderLatency">Order Latency (ms):</label>
+                <input type="number" id="orderLatency" value="0" step="10" min="0">
+            </div>
+            
+            <div class="form-group">
+                <label for="reportLatency">Report Latency (ms):</label>
+                <input type="number" id="reportLatency" value="0" step="10" min="0">
+            </div>
+            
+            <div class="form-group">
+                <label for="participation">Max Participation (% of trade qty, 0 = no cap):</label>
+                <input type="number" id="participation" value="0" step="5" min="0" max="100">
+            </div>
+            
             <div class="form-group">
                 <label>Strategy Params:</label>
-                <div class="strategy-params" id="strategyParams">
-                    <label for="bbPeriod">Period:</label>
-                    <input type="number" id="bbPeriod" value="100" min="2">
-                    <label for="bbStdDev">Standard Deviations:</label>
-                    <input type="number" id="bbStdDev" value="1" step="0.1" min="0.1">
-                </div>
+                <div class="strategy-params" id="strategyParams"></div>
             </div>
             
             <button onclick="runBacktest()">Run Backtest</button>
+            <button onclick="downloadDecisionLog()">Download Decision Log</button>
         </section>
         
         <section class="results-section">
@@ -64,6 +156,11 @@ func GetHTMLTemplate() string {
                 <!-- Metrics will be populated here -->
             </div>
             
+            <div class="chart-container">
+                <h3>Price Chart</h3>
+                <div id="priceChart"></div>
+            </div>
+            
             <div class="chart-container">
                 <h3>Equity Curve</h3>
                 <div id="equityChart"></div>